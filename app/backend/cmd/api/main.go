@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 	"truora-backend/internal/app/handlers"
 	"truora-backend/internal/app/router"
 	"truora-backend/internal/pkg/repository"
@@ -30,19 +31,42 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
-	// Initialize repository
+	// Initialize repositories
 	stockRepo := repository.NewStockRepository(db.DB)
+	priceRepo := repository.NewPriceRepository(db.DB)
+	brokerageRepo := repository.NewBrokerageRepository(db.DB)
+	sectorRepo := repository.NewSectorRepository(db.DB)
+	portfolioRepo := repository.NewPortfolioRepository(db.DB)
+	anomalyRepo := repository.NewAnomalyRepository(db.DB)
+	statsRepo := repository.NewStatsRepository(db.DB)
 
-	// Initialize service
+	// Initialize services
 	apiURL := getEnv("STOCK_API_URL", "https://api")
 	apiKey := getEnv("STOCK_API_KEY", "Bearer ")
-	stockService := service.NewStockService(stockRepo, apiURL, apiKey)
+	config := service.ConfigFromEnv()
+	stockService := service.NewStockService(stockRepo, priceRepo, brokerageRepo, sectorRepo, apiURL, apiKey, config)
+	priceService := service.NewPriceService(priceRepo)
+	backtestService := service.NewBacktestService(stockRepo, priceRepo, config)
+	brokerageService := service.NewBrokerageService(stockRepo, priceRepo, brokerageRepo, config)
+	sectorService := service.NewSectorService(sectorRepo)
+	portfolioService := service.NewPortfolioService(portfolioRepo, stockRepo, sectorRepo)
+	anomalyService := service.NewAnomalyService(anomalyRepo, stockRepo, config)
+	statsService := service.NewStatsService(statsRepo, getEnvDuration("STATS_CACHE_TTL", 10*time.Minute))
+	parquetService := service.NewParquetService(stockRepo)
 
 	// Initialize handlers
-	stockHandler := handlers.NewStockHandler(stockService)
+	stockHandler := handlers.NewStockHandler(stockService, anomalyService)
+	priceHandler := handlers.NewPriceHandler(priceService)
+	backtestHandler := handlers.NewBacktestHandler(backtestService)
+	brokerageHandler := handlers.NewBrokerageHandler(brokerageService)
+	sectorHandler := handlers.NewSectorHandler(sectorService)
+	portfolioHandler := handlers.NewPortfolioHandler(portfolioService)
+	anomalyHandler := handlers.NewAnomalyHandler(anomalyService)
+	statsHandler := handlers.NewStatsHandler(statsService)
+	adminHandler := handlers.NewAdminHandler(parquetService, getEnv("ADMIN_TOKEN", ""), getEnv("PARQUET_EXPORT_DIR", "./data/parquet"))
 
 	// Setup router
-	r := router.SetupRouter(stockHandler)
+	r := router.SetupRouter(stockHandler, priceHandler, backtestHandler, brokerageHandler, sectorHandler, portfolioHandler, anomalyHandler, statsHandler, adminHandler)
 
 	// Get port from environment
 	port := getEnv("PORT", "8000")
@@ -64,3 +88,14 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvDuration gets environment variable as duration with fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+		log.Printf("Invalid duration format for %s: %s, using fallback", key, value)
+	}
+	return fallback
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/repository"
+	"truora-backend/internal/pkg/service"
+	"truora-backend/internal/platform/cockroachdb"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	start := flag.String("start", "", "First rebalance date (YYYY-MM-DD)")
+	end := flag.String("end", "", "Last rebalance date (YYYY-MM-DD)")
+	step := flag.Int("step", 7, "Days between rebalance dates")
+	horizons := flag.String("horizons", "30,90", "Comma-separated forward-return horizons in days")
+	strategy := flag.String("strategy", models.StrategyTopN, "Pick strategy: top_n or threshold")
+	topN := flag.Int("top", 10, "Number of picks for the top_n strategy")
+	minScore := flag.Float64("min-score", 60, "Minimum score for the threshold strategy")
+	profile := flag.String("profile", "", "Scoring profile: "+strings.Join(service.ScoringProfileNames(), ", "))
+	lookback := flag.Int("lookback", 0, "Only use events from the last N days (0 = all history)")
+	benchmark := flag.String("benchmark", "", "Benchmark ticker (default: equal-weighted universe)")
+	flag.Parse()
+
+	startDate, err := time.Parse("2006-01-02", *start)
+	if err != nil {
+		log.Fatalf("Invalid -start date %q, expected YYYY-MM-DD", *start)
+	}
+	endDate, err := time.Parse("2006-01-02", *end)
+	if err != nil {
+		log.Fatalf("Invalid -end date %q, expected YYYY-MM-DD", *end)
+	}
+
+	var horizonDays []int
+	for _, value := range strings.Split(*horizons, ",") {
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			log.Fatalf("Invalid -horizons value %q", value)
+		}
+		horizonDays = append(horizonDays, days)
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Initialize database connection
+	db, err := cockroachdb.NewConnection()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	backtestService := service.NewBacktestService(
+		repository.NewStockRepository(db.DB),
+		repository.NewPriceRepository(db.DB),
+		service.ConfigFromEnv(),
+	)
+
+	// Stop the backtest on interrupt signals
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := backtestService.Run(ctx, models.BacktestConfig{
+		Start:        startDate,
+		End:          endDate,
+		StepDays:     *step,
+		HorizonDays:  horizonDays,
+		Strategy:     *strategy,
+		TopN:         *topN,
+		MinScore:     minScore,
+		Profile:      *profile,
+		LookbackDays: *lookback,
+		Benchmark:    *benchmark,
+	})
+	if err != nil {
+		log.Fatalf("Backtest failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Fatalf("Failed to write result: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"truora-backend/internal/pkg/repository"
+	"truora-backend/internal/pkg/service"
+	"truora-backend/internal/platform/cockroachdb"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dir := flag.String("dir", "./data/parquet", "directory the Parquet files are written under")
+	partition := flag.Bool("partition-by-month", false, "write analyst events and runs to one file per month")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Initialize database connection
+	db, err := cockroachdb.NewConnection()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Run migrations
+	if err := cockroachdb.RunMigrations(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	parquetService := service.NewParquetService(repository.NewStockRepository(db.DB))
+
+	files, err := parquetService.Export(context.Background(), service.ParquetOptions{
+		Dir:              *dir,
+		PartitionByMonth: *partition,
+	})
+	if err != nil {
+		log.Fatalf("Parquet export failed: %v", err)
+	}
+	for _, file := range files {
+		log.Printf("Wrote %d %s rows to %s", file.Rows, file.Dataset, file.Path)
+	}
+	log.Printf("Exported %d Parquet files to %s", len(files), *dir)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"truora-backend/internal/pkg/repository"
+	"truora-backend/internal/pkg/service"
+	"truora-backend/internal/platform/cockroachdb"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	path := flag.String("path", "", "CSV file or directory of CSV files with daily prices")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("Usage: prices -path <file.csv|directory>")
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Initialize database connection
+	db, err := cockroachdb.NewConnection()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Run migrations
+	if err := cockroachdb.RunMigrations(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	priceService := service.NewPriceService(repository.NewPriceRepository(db.DB))
+
+	count, err := priceService.ImportCSV(*path)
+	if err != nil {
+		log.Fatalf("Price import failed after %d prices: %v", count, err)
+	}
+	log.Printf("Imported %d prices from %s", count, *path)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"truora-backend/internal/pkg/repository"
+	"truora-backend/internal/pkg/service"
+	"truora-backend/internal/platform/cockroachdb"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	path := flag.String("path", "", "CSV file with ticker, sector and industry columns")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("Usage: sectors -path <file.csv>")
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Initialize database connection
+	db, err := cockroachdb.NewConnection()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Run migrations
+	if err := cockroachdb.RunMigrations(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sectorService := service.NewSectorService(repository.NewSectorRepository(db.DB))
+
+	count, err := sectorService.ImportCSV(*path)
+	if err != nil {
+		log.Fatalf("Sector import failed: %v", err)
+	}
+	log.Printf("Imported %d ticker sectors from %s", count, *path)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -26,27 +27,40 @@ func main() {
 	}
 	defer db.Close()
 
-	// Initialize repository and service
+	// Initialize repositories and services
 	stockRepo := repository.NewStockRepository(db.DB)
+	priceRepo := repository.NewPriceRepository(db.DB)
+	brokerageRepo := repository.NewBrokerageRepository(db.DB)
+	sectorRepo := repository.NewSectorRepository(db.DB)
 	apiURL := getEnv("STOCK_API_URL", "https://api")
 	apiKey := getEnv("STOCK_API_KEY", "Bearer ")
-	stockService := service.NewStockService(stockRepo, apiURL, apiKey)
+	config := service.ConfigFromEnv()
+	stockService := service.NewStockService(stockRepo, priceRepo, brokerageRepo, sectorRepo, apiURL, apiKey, config)
+	priceService := service.NewPriceService(priceRepo)
+	brokerageService := service.NewBrokerageService(stockRepo, priceRepo, brokerageRepo, config)
+	sectorService := service.NewSectorService(sectorRepo)
+	anomalyService := service.NewAnomalyService(repository.NewAnomalyRepository(db.DB), stockRepo, config)
+	priceDataDir := os.Getenv("PRICE_DATA_DIR")     // Optional: directory of daily price CSV files
+	sectorDataFile := os.Getenv("SECTOR_DATA_FILE") // Optional: CSV of ticker sectors and industries
 
 	log.Println("Starting Truora Stock Worker...")
 
-	// Create a channel to listen for interrupt signals
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	// Cancel the context on interrupt signals so running tasks stop mid-flight
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Create a ticker for periodic tasks
 	dataFetchInterval := getEnvDuration("DATA_FETCH_INTERVAL", 6*time.Hour)           // Default: every 6 hours
 	recommendationInterval := getEnvDuration("RECOMMENDATION_INTERVAL", 24*time.Hour) // Default: daily
+	trackRecordInterval := getEnvDuration("TRACK_RECORD_INTERVAL", 24*time.Hour)      // Default: daily
 
 	dataFetchTicker := time.NewTicker(dataFetchInterval)
 	recommendationTicker := time.NewTicker(recommendationInterval)
+	trackRecordTicker := time.NewTicker(trackRecordInterval)
 
 	defer dataFetchTicker.Stop()
 	defer recommendationTicker.Stop()
+	defer trackRecordTicker.Stop()
 
 	// Run initial tasks
 	log.Println("Running initial data fetch...")
@@ -56,8 +70,22 @@ func main() {
 		log.Println("Initial data fetch completed successfully")
 	}
 
+	// The first scan covers every ticker, later ones only tickers touched by a fetch
+	detectAnomalies(ctx, anomalyService, time.Time{})
+
+	importPrices(priceService, priceDataDir)
+	importSectors(sectorService, sectorDataFile)
+
+	// Credibility weights feed the recommendation score, so evaluate them first
+	log.Println("Evaluating initial brokerage track records...")
+	if _, err := brokerageService.EvaluateTrackRecords(ctx); err != nil {
+		log.Printf("Initial track record evaluation failed: %v", err)
+	} else {
+		log.Println("Initial track records evaluated successfully")
+	}
+
 	log.Println("Generating initial recommendations...")
-	if err := stockService.GenerateRecommendations(); err != nil {
+	if _, err := stockService.GenerateRecommendations(ctx, service.GenerateOptions{}); err != nil {
 		log.Printf("Initial recommendation generation failed: %v", err)
 	} else {
 		log.Println("Initial recommendations generated successfully")
@@ -70,27 +98,77 @@ func main() {
 		select {
 		case <-dataFetchTicker.C:
 			log.Println("Starting scheduled data fetch...")
+			fetchStartedAt := time.Now()
 			if err := stockService.FetchAndStoreStocks(); err != nil {
 				log.Printf("Scheduled data fetch failed: %v", err)
 			} else {
 				log.Println("Scheduled data fetch completed successfully")
+				detectAnomalies(ctx, anomalyService, fetchStartedAt)
 			}
+			importPrices(priceService, priceDataDir)
+			importSectors(sectorService, sectorDataFile)
 
 		case <-recommendationTicker.C:
 			log.Println("Starting scheduled recommendation generation...")
-			if err := stockService.GenerateRecommendations(); err != nil {
+			if _, err := stockService.GenerateRecommendations(ctx, service.GenerateOptions{}); err != nil {
 				log.Printf("Scheduled recommendation generation failed: %v", err)
 			} else {
 				log.Println("Scheduled recommendations generated successfully")
 			}
 
-		case <-c:
+		case <-trackRecordTicker.C:
+			log.Println("Starting scheduled track record evaluation...")
+			if _, err := brokerageService.EvaluateTrackRecords(ctx); err != nil {
+				log.Printf("Scheduled track record evaluation failed: %v", err)
+			} else {
+				log.Println("Scheduled track records evaluated successfully")
+			}
+
+		case <-ctx.Done():
 			log.Println("Received interrupt signal, shutting down worker...")
 			return
 		}
 	}
 }
 
+// importPrices imports daily prices from dir when it is configured
+func importPrices(priceService service.PriceService, dir string) {
+	if dir == "" {
+		return
+	}
+
+	log.Printf("Importing prices from %s...", dir)
+	count, err := priceService.ImportCSV(dir)
+	if err != nil {
+		log.Printf("Price import failed after %d prices: %v", count, err)
+		return
+	}
+	log.Printf("Imported %d prices", count)
+}
+
+// detectAnomalies flags unusual analyst activity on tickers with events stored since the given time
+func detectAnomalies(ctx context.Context, anomalyService service.AnomalyService, since time.Time) {
+	log.Println("Detecting anomalies...")
+	count, err := anomalyService.DetectAnomalies(ctx, since)
+	if err != nil {
+		log.Printf("Anomaly detection failed: %v", err)
+		return
+	}
+	log.Printf("Anomaly detection found %d anomalies", count)
+}
+
+// importSectors loads ticker sectors from file when it is configured
+func importSectors(sectorService service.SectorService, file string) {
+	if file == "" {
+		return
+	}
+
+	log.Printf("Importing sectors from %s...", file)
+	if _, err := sectorService.ImportCSV(file); err != nil {
+		log.Printf("Sector import failed: %v", err)
+	}
+}
+
 // getEnv gets environment variable with fallback
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
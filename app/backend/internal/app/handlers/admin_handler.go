@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"truora-backend/internal/pkg/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	parquetService service.ParquetService
+	token          string
+	parquetDir     string
+}
+
+// NewAdminHandler creates a new admin handler. Admin requests must carry token as a bearer token;
+// with an empty token every admin route is disabled. Parquet exports are written under parquetDir.
+func NewAdminHandler(parquetService service.ParquetService, token, parquetDir string) *AdminHandler {
+	return &AdminHandler{
+		parquetService: parquetService,
+		token:          token,
+		parquetDir:     parquetDir,
+	}
+}
+
+// Authorize rejects admin requests without the configured bearer token
+func (h *AdminHandler) Authorize(c *gin.Context) {
+	if h.token == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Admin endpoints are disabled, set ADMIN_TOKEN to enable them",
+		})
+		return
+	}
+
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Missing or invalid admin token",
+		})
+		return
+	}
+	c.Next()
+}
+
+// ExportParquet handles POST /api/admin/exports/parquet
+func (h *AdminHandler) ExportParquet(c *gin.Context) {
+	partition, err := strconv.ParseBool(c.DefaultQuery("partition_by_month", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid partition_by_month parameter, expected true or false",
+		})
+		return
+	}
+
+	files, err := h.parquetService.Export(c.Request.Context(), service.ParquetOptions{
+		Dir:              h.parquetDir,
+		PartitionByMonth: partition,
+	})
+	if errors.Is(err, service.ErrExportInProgress) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A Parquet export is already running",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to export Parquet files",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Parquet export written successfully",
+		"data":    files,
+		"count":   len(files),
+	})
+}
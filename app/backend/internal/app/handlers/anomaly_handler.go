@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnomalyHandler struct {
+	anomalyService service.AnomalyService
+}
+
+// NewAnomalyHandler creates a new anomaly handler
+func NewAnomalyHandler(anomalyService service.AnomalyService) *AnomalyHandler {
+	return &AnomalyHandler{
+		anomalyService: anomalyService,
+	}
+}
+
+// GetAnomalies handles GET /api/anomalies
+func (h *AnomalyHandler) GetAnomalies(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	days, ok := parseDays(c, 7)
+	if !ok {
+		return
+	}
+
+	filter := models.AnomalyFilter{
+		Ticker:   strings.ToUpper(c.Query("ticker")),
+		Type:     c.Query("type"),
+		Severity: c.Query("severity"),
+		Since:    time.Now().AddDate(0, 0, -days),
+		Limit:    limit,
+	}
+
+	switch filter.Type {
+	case "", models.AnomalyDowngradeBurst, models.AnomalyTargetCut, models.AnomalyCoverageInitiation, models.AnomalyRatingReversal:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid type parameter, expected downgrade_burst, target_cut, coverage_initiation or rating_reversal",
+		})
+		return
+	}
+
+	switch filter.Severity {
+	case "", models.SeverityLow, models.SeverityMedium, models.SeverityHigh:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid severity parameter, expected low, medium or high",
+		})
+		return
+	}
+
+	anomalies, err := h.anomalyService.GetAnomalies(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve anomalies",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  anomalies,
+		"count": len(anomalies),
+	})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BacktestHandler struct {
+	backtestService service.BacktestService
+}
+
+// backtestRequest is the body of POST /api/backtests; dates are YYYY-MM-DD or RFC 3339
+type backtestRequest struct {
+	Start        string   `json:"start" binding:"required"`
+	End          string   `json:"end" binding:"required"`
+	StepDays     int      `json:"step_days"`
+	HorizonDays  []int    `json:"horizon_days"`
+	Strategy     string   `json:"strategy"`
+	TopN         int      `json:"top_n"`
+	MinScore     *float64 `json:"min_score"`
+	Profile      string   `json:"profile"`
+	LookbackDays int      `json:"lookback_days"`
+	Benchmark    string   `json:"benchmark"`
+}
+
+// NewBacktestHandler creates a new backtest handler
+func NewBacktestHandler(backtestService service.BacktestService) *BacktestHandler {
+	return &BacktestHandler{
+		backtestService: backtestService,
+	}
+}
+
+// RunBacktest handles POST /api/backtests
+func (h *BacktestHandler) RunBacktest(c *gin.Context) {
+	var request backtestRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid backtest request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	start, err := parseRequestDate(request.Start)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid start date, expected YYYY-MM-DD",
+		})
+		return
+	}
+	end, err := parseRequestDate(request.End)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid end date, expected YYYY-MM-DD",
+		})
+		return
+	}
+
+	result, err := h.backtestService.Run(c.Request.Context(), models.BacktestConfig{
+		Start:        start,
+		End:          end,
+		StepDays:     request.StepDays,
+		HorizonDays:  request.HorizonDays,
+		Strategy:     request.Strategy,
+		TopN:         request.TopN,
+		MinScore:     request.MinScore,
+		Profile:      request.Profile,
+		LookbackDays: request.LookbackDays,
+		Benchmark:    request.Benchmark,
+	})
+	if errors.Is(err, service.ErrInvalidBacktestConfig) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid backtest configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to run backtest",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
+
+// parseRequestDate parses a YYYY-MM-DD or RFC 3339 date from a request body
+func parseRequestDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"truora-backend/internal/pkg/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BrokerageHandler struct {
+	brokerageService service.BrokerageService
+}
+
+// NewBrokerageHandler creates a new brokerage handler
+func NewBrokerageHandler(brokerageService service.BrokerageService) *BrokerageHandler {
+	return &BrokerageHandler{
+		brokerageService: brokerageService,
+	}
+}
+
+// GetLeaderboard handles GET /api/brokerages/leaderboard
+func (h *BrokerageHandler) GetLeaderboard(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", "20")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	minCalls, err := strconv.Atoi(c.DefaultQuery("min_calls", "5"))
+	if err != nil || minCalls < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid min_calls parameter, expected a non-negative integer",
+		})
+		return
+	}
+
+	scores, err := h.brokerageService.GetLeaderboard(minCalls, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve brokerage leaderboard",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  scores,
+		"count": len(scores),
+	})
+}
+
+// GetBrokerages handles GET /api/brokerages
+func (h *BrokerageHandler) GetBrokerages(c *gin.Context) {
+	brokerages, err := h.brokerageService.GetDirectory()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve brokerages",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  brokerages,
+		"count": len(brokerages),
+	})
+}
+
+// GetBrokerage handles GET /api/brokerages/:id
+func (h *BrokerageHandler) GetBrokerage(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", "20")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	profile, err := h.brokerageService.GetProfile(c.Param("id"), limit)
+	if errors.Is(err, service.ErrAmbiguousBrokerage) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Brokerage id matches several brokerages, use the full name as id",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve brokerage",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if profile == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Brokerage not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": profile,
+	})
+}
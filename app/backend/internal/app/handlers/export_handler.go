@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/export"
+	"truora-backend/internal/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportStocks handles GET /api/stocks/export
+func (h *StockHandler) ExportStocks(c *gin.Context) {
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseStockFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Sort, ok = parseSort(c, models.StockSortFields, "time", "target", "upside"); !ok {
+		return
+	}
+
+	streamExport(c, "stocks", format, export.StockColumns, func(ctx context.Context, write func([]interface{}) error) error {
+		return h.stockService.StreamStocks(ctx, filter, func(stock models.Stock) error {
+			return write(export.StockRow(stock))
+		})
+	})
+}
+
+// ExportRecommendations handles GET /api/recommendations/export
+func (h *StockHandler) ExportRecommendations(c *gin.Context) {
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseRecommendationFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Sort, ok = parseSort(c, models.RecommendationSortFields, "score", "return"); !ok {
+		return
+	}
+
+	streamExport(c, "recommendations", format, export.RecommendationColumns, func(ctx context.Context, write func([]interface{}) error) error {
+		return h.stockService.StreamRecommendations(ctx, filter, func(recommendation models.StockRecommendation) error {
+			return write(export.RecommendationRow(recommendation))
+		})
+	})
+}
+
+// parseExportFormat reads the export format from the format parameter, or else from the Accept
+// header, defaulting to CSV. It writes a 400 response when the format parameter is unsupported.
+func parseExportFormat(c *gin.Context) (export.Format, bool) {
+	if name := c.Query("format"); name != "" {
+		format, err := export.ParseFormat(name)
+		if err != nil {
+			formats := make([]string, len(export.Formats))
+			for i, f := range export.Formats {
+				formats[i] = string(f)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid format parameter, expected " + strings.Join(formats, ", "),
+			})
+			return "", false
+		}
+		return format, true
+	}
+	if format, ok := export.FormatFromAccept(c.GetHeader("Accept")); ok {
+		return format, true
+	}
+	return export.FormatCSV, true
+}
+
+// streamExport writes the rows produced by stream as an attachment in a format. The response starts
+// with the first row, so a failure before it still gets an error response; a failure after it can
+// only cut the download short.
+func streamExport(c *gin.Context, name string, format export.Format, columns []string,
+	stream func(ctx context.Context, write func([]interface{}) error) error) {
+	var encoder export.Encoder
+	start := func() error {
+		filename := name + "-" + time.Now().Format("2006-01-02") + "." + format.Extension()
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Status(http.StatusOK)
+
+		var err error
+		encoder, err = export.NewEncoder(format, c.Writer, columns)
+		return err
+	}
+
+	err := stream(c.Request.Context(), func(values []interface{}) error {
+		if encoder == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return encoder.WriteRow(values)
+	})
+	if err != nil && encoder == nil && !c.Writer.Written() {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to export " + name,
+			"details": err.Error(),
+		})
+		return
+	}
+	if err == nil && encoder == nil {
+		// No matching rows: the file holds only the header
+		err = start()
+	}
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil {
+		log.Printf("Export of %s failed mid-stream: %v", name, err)
+		c.Abort()
+	}
+}
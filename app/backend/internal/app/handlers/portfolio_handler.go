@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PortfolioHandler struct {
+	portfolioService service.PortfolioService
+}
+
+// NewPortfolioHandler creates a new portfolio handler
+func NewPortfolioHandler(portfolioService service.PortfolioService) *PortfolioHandler {
+	return &PortfolioHandler{
+		portfolioService: portfolioService,
+	}
+}
+
+// BuildPortfolio handles POST /api/portfolios/build
+func (h *PortfolioHandler) BuildPortfolio(c *gin.Context) {
+	var config models.PortfolioConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid portfolio request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	portfolio, err := h.portfolioService.BuildPortfolio(config)
+	if errors.Is(err, service.ErrInvalidPortfolioConfig) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid portfolio configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to build portfolio",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": portfolio,
+	})
+}
+
+// GetPortfolios handles GET /api/portfolios
+func (h *PortfolioHandler) GetPortfolios(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	portfolios, err := h.portfolioService.GetPortfolios(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve portfolios",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  portfolios,
+		"count": len(portfolios),
+		"pagination": gin.H{
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// GetPortfolio handles GET /api/portfolios/:id
+func (h *PortfolioHandler) GetPortfolio(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid portfolio id",
+		})
+		return
+	}
+
+	portfolio, err := h.portfolioService.GetPortfolio(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve portfolio",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if portfolio == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Portfolio not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": portfolio,
+	})
+}
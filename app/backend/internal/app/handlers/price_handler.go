@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"truora-backend/internal/pkg/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PriceHandler struct {
+	priceService service.PriceService
+}
+
+// NewPriceHandler creates a new price handler
+func NewPriceHandler(priceService service.PriceService) *PriceHandler {
+	return &PriceHandler{
+		priceService: priceService,
+	}
+}
+
+// GetPrices handles GET /api/stocks/:ticker/prices
+func (h *PriceHandler) GetPrices(c *gin.Context) {
+	ticker := c.Param("ticker")
+	if ticker == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Ticker parameter is required",
+		})
+		return
+	}
+
+	from, ok := parseDateQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseDateQuery(c, "to")
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "365"))
+	if err != nil || limit <= 0 || limit > 5000 {
+		limit = 365
+	}
+
+	prices, err := h.priceService.GetPrices(ticker, from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve prices",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  prices,
+		"count": len(prices),
+	})
+}
+
+// parseDateQuery reads an optional YYYY-MM-DD query parameter, writing a 400 response when it is invalid
+func parseDateQuery(c *gin.Context, name string) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, true
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + name + " parameter, expected YYYY-MM-DD",
+		})
+		return time.Time{}, false
+	}
+	return date, true
+}
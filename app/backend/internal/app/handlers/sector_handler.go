@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SectorHandler struct {
+	sectorService service.SectorService
+}
+
+// NewSectorHandler creates a new sector handler
+func NewSectorHandler(sectorService service.SectorService) *SectorHandler {
+	return &SectorHandler{
+		sectorService: sectorService,
+	}
+}
+
+// GetSectors handles GET /api/sectors
+func (h *SectorHandler) GetSectors(c *gin.Context) {
+	horizon, days, top, ok := parseSectorQuery(c, 3)
+	if !ok {
+		return
+	}
+
+	sectors, err := h.sectorService.GetSectors(horizon, days, top)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve sectors",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  sectors,
+		"count": len(sectors),
+	})
+}
+
+// GetSector handles GET /api/sectors/:sector
+func (h *SectorHandler) GetSector(c *gin.Context) {
+	name := c.Param("sector")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Sector parameter is required",
+		})
+		return
+	}
+
+	horizon, days, top, ok := parseSectorQuery(c, 10)
+	if !ok {
+		return
+	}
+
+	sector, err := h.sectorService.GetSector(name, horizon, days, top)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve sector",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if sector == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Sector not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": sector,
+	})
+}
+
+// parseSectorQuery reads the time_horizon, days and top query parameters of the sector endpoints
+func parseSectorQuery(c *gin.Context, defaultTop int) (string, int, int, bool) {
+	horizon, ok := parseTimeHorizon(c, models.TimeHorizonMedium)
+	if !ok {
+		return "", 0, 0, false
+	}
+
+	days, ok := parseDays(c, 30)
+	if !ok {
+		return "", 0, 0, false
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", strconv.Itoa(defaultTop)))
+	if err != nil || top < 0 || top > 50 {
+		top = defaultTop
+	}
+	return horizon, days, top, true
+}
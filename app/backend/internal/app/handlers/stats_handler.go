@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"truora-backend/internal/pkg/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	statsService service.StatsService
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(statsService service.StatsService) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetOverview handles GET /api/stats/overview
+func (h *StatsHandler) GetOverview(c *gin.Context) {
+	overview, err := h.statsService.GetOverview()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve market overview",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": overview,
+	})
+}
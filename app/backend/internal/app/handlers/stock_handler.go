@@ -1,21 +1,28 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
 	"truora-backend/internal/pkg/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type StockHandler struct {
-	stockService service.StockService
+	stockService   service.StockService
+	anomalyService service.AnomalyService
 }
 
 // NewStockHandler creates a new stock handler
-func NewStockHandler(stockService service.StockService) *StockHandler {
+func NewStockHandler(stockService service.StockService, anomalyService service.AnomalyService) *StockHandler {
 	return &StockHandler{
-		stockService: stockService,
+		stockService:   stockService,
+		anomalyService: anomalyService,
 	}
 }
 
@@ -24,7 +31,6 @@ func (h *StockHandler) GetStocks(c *gin.Context) {
 	// Parse query parameters
 	limitStr := c.DefaultQuery("limit", "20")
 	offsetStr := c.DefaultQuery("offset", "0")
-	query := c.Query("q")
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit <= 0 || limit > 100 {
@@ -36,45 +42,295 @@ func (h *StockHandler) GetStocks(c *gin.Context) {
 		offset = 0
 	}
 
-	var stocks interface{}
-	var serviceErr error
+	filter, ok := parseStockFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Sort, ok = parseSort(c, models.StockSortFields, "time", "target", "upside"); !ok {
+		return
+	}
+	if filter.After, ok = parseCursor(c, offset); !ok {
+		return
+	}
+	filter.Limit = limit
+	filter.Offset = offset
 
-	// If query parameter is provided, search stocks
-	if query != "" {
-		stocks, serviceErr = h.stockService.SearchStocks(query, limit, offset)
-	} else {
-		stocks, serviceErr = h.stockService.GetAllStocks(limit, offset)
+	withTotal, ok := parseCount(c)
+	if !ok {
+		return
 	}
 
-	if serviceErr != nil {
+	page, err := h.stockService.GetStocks(filter, withTotal)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid cursor parameter",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to retrieve stocks",
-			"details": serviceErr.Error(),
+			"details": err.Error(),
 		})
 		return
 	}
 
-	// Get total count for pagination
-	totalCount, err := h.stockService.GetStockCount()
+	c.JSON(http.StatusOK, gin.H{
+		"data":       page.Stocks,
+		"pagination": pagination(limit, offset, page.Total, page.Next),
+	})
+}
+
+// parseCount reads the optional count parameter, true by default, writing a 400 response when it
+// is not a boolean
+func parseCount(c *gin.Context) (bool, bool) {
+	value := c.Query("count")
+	if value == "" {
+		return true, true
+	}
+	withTotal, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid count parameter, expected true or false",
+		})
+		return false, false
+	}
+	return withTotal, true
+}
+
+// pagination describes a listing page: its limit and offset, the rows matching the listing when
+// counted and the cursor of the next page
+func pagination(limit, offset int, total *int64, next *models.Cursor) gin.H {
+	return gin.H{
+		"limit":       limit,
+		"offset":      offset,
+		"total":       total,
+		"next_cursor": encodeCursor(next),
+	}
+}
+
+// parseCursor reads the optional cursor parameter, writing a 400 response when it is malformed or
+// combined with an offset
+func parseCursor(c *gin.Context, offset int) (*models.Cursor, bool) {
+	token := c.Query("cursor")
+	if token == "" {
+		return nil, true
+	}
+	if offset > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The cursor and offset parameters cannot be combined",
+		})
+		return nil, false
+	}
+
+	cursor, err := models.DecodeCursor(token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor parameter",
+		})
+		return nil, false
+	}
+	return cursor, true
+}
+
+// encodeCursor returns a cursor's token, or nil on the last page
+func encodeCursor(cursor *models.Cursor) interface{} {
+	if cursor == nil {
+		return nil
+	}
+	return cursor.Encode()
+}
+
+// parseStockFilter reads the event filters of a stocks listing, writing a 400 response when one
+// is invalid. The to date is inclusive.
+func parseStockFilter(c *gin.Context) (models.StockFilter, bool) {
+	filter := models.StockFilter{
+		Query:      c.Query("q"),
+		Brokerage:  c.Query("brokerage"),
+		Action:     c.Query("action"),
+		RatingFrom: c.Query("rating_from"),
+		RatingTo:   c.Query("rating_to"),
+	}
+
+	from, ok := parseDateQuery(c, "from")
+	if !ok {
+		return filter, false
+	}
+	to, ok := parseDateQuery(c, "to")
+	if !ok {
+		return filter, false
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid date range, from must not be after to",
+		})
+		return filter, false
+	}
+	filter.From = from
+	if !to.IsZero() {
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	if filter.MinTarget, ok = parseFloatQuery(c, "target_min"); !ok {
+		return filter, false
+	}
+	if filter.MaxTarget, ok = parseFloatQuery(c, "target_max"); !ok {
+		return filter, false
+	}
+	if filter.MinTarget != nil && filter.MaxTarget != nil && *filter.MaxTarget < *filter.MinTarget {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid target range, target_min must not exceed target_max",
+		})
+		return filter, false
+	}
+	return filter, true
+}
+
+// parseSort reads the comma-separated sort fields of a listing and their order, one direction per
+// field or a single direction for all of them. Without an order, the descending fields sort
+// descending and the others ascending. It writes a 400 response when either is invalid.
+func parseSort(c *gin.Context, fields []string, descending ...string) ([]models.SortKey, bool) {
+	sortParam := c.Query("sort")
+	if sortParam == "" {
+		return nil, true
+	}
+
+	names := strings.Split(sortParam, ",")
+	var orders []string
+	if orderParam := c.Query("order"); orderParam != "" {
+		orders = strings.Split(orderParam, ",")
+	}
+	if len(orders) > 1 && len(orders) != len(names) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid order parameter, expected one direction or one per sort field",
+		})
+		return nil, false
+	}
+
+	keys := make([]models.SortKey, 0, len(names))
+	seen := make(map[string]bool)
+	for i, field := range names {
+		field = strings.TrimSpace(field)
+		if !containsField(fields, field) || seen[field] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid sort parameter, expected distinct fields among " + strings.Join(fields, ", "),
+			})
+			return nil, false
+		}
+		seen[field] = true
+
+		key := models.SortKey{Field: field, Desc: containsField(descending, field)}
+		if len(orders) > 0 {
+			order := orders[0]
+			if len(orders) > 1 {
+				order = orders[i]
+			}
+			switch strings.ToLower(strings.TrimSpace(order)) {
+			case "asc":
+				key.Desc = false
+			case "desc":
+				key.Desc = true
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Invalid order parameter, expected asc or desc",
+				})
+				return nil, false
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys, true
+}
+
+// containsField reports whether fields contains field
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFloatQuery reads an optional non-negative number query parameter, writing a 400 response
+// when it is invalid
+func parseFloatQuery(c *gin.Context, name string) (*float64, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + name + " parameter, expected a non-negative number",
+		})
+		return nil, false
+	}
+	return &value, true
+}
+
+// GetStockDetail handles GET /api/stocks/:ticker
+func (h *StockHandler) GetStockDetail(c *gin.Context) {
+	ticker := c.Param("ticker")
+	if ticker == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Ticker parameter is required",
+		})
+		return
+	}
+
+	horizon, ok := parseTimeHorizon(c, models.TimeHorizonMedium)
+	if !ok {
+		return
+	}
+	days, ok := parseDays(c, 90)
+	if !ok {
+		return
+	}
+
+	filter := models.TimelineFilter{Brokerage: c.Query("brokerage")}
+	if filter.From, ok = parseDateQuery(c, "from"); !ok {
+		return
+	}
+	to, ok := parseDateQuery(c, "to")
+	if !ok {
+		return
+	}
+	if !filter.From.IsZero() && !to.IsZero() && to.Before(filter.From) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid date range, from must not be after to",
+		})
+		return
+	}
+	if !to.IsZero() {
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	detail, err := h.stockService.GetStockDetail(ticker, horizon, days, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get stock count",
+			"error":   "Failed to retrieve stock detail",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if detail == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Stock not found",
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data": stocks,
-		"pagination": gin.H{
-			"limit":  limit,
-			"offset": offset,
-			"total":  totalCount,
-		},
+		"data": detail,
 	})
 }
 
-// GetStockByTicker handles GET /api/stocks/:ticker
-func (h *StockHandler) GetStockByTicker(c *gin.Context) {
+// GetConsensus handles GET /api/stocks/:ticker/consensus
+func (h *StockHandler) GetConsensus(c *gin.Context) {
 	ticker := c.Param("ticker")
 	if ticker == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -83,29 +339,30 @@ func (h *StockHandler) GetStockByTicker(c *gin.Context) {
 		return
 	}
 
-	stock, err := h.stockService.GetByTicker(ticker)
+	consensus, err := h.stockService.GetConsensus(ticker)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve stock",
+			"error":   "Failed to retrieve consensus",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	if stock == nil {
+	if consensus == nil {
 		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Stock not found",
+			"error": "No analyst coverage found for ticker",
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data": stock,
+		"data": consensus,
 	})
 }
 
 // FetchStocks handles POST /api/stocks/fetch
 func (h *StockHandler) FetchStocks(c *gin.Context) {
+	startedAt := time.Now()
 	err := h.stockService.FetchAndStoreStocks()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -115,14 +372,41 @@ func (h *StockHandler) FetchStocks(c *gin.Context) {
 		return
 	}
 
+	// The events are stored either way, so a detector failure is only logged
+	anomalies, err := h.anomalyService.DetectAnomalies(c.Request.Context(), startedAt)
+	if err != nil {
+		log.Printf("Anomaly detection failed: %v", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Stocks fetched and stored successfully",
+		"message":   "Stocks fetched and stored successfully",
+		"anomalies": anomalies,
 	})
 }
 
 // GenerateRecommendations handles POST /api/recommendations/generate
 func (h *StockHandler) GenerateRecommendations(c *gin.Context) {
-	err := h.stockService.GenerateRecommendations()
+	full, err := strconv.ParseBool(c.DefaultQuery("full", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid full parameter, expected true or false",
+		})
+		return
+	}
+
+	run, err := h.stockService.GenerateRecommendations(c.Request.Context(), service.GenerateOptions{Full: full})
+	if tickerErrs := service.TickerErrors(err); len(tickerErrs) > 0 {
+		failures := make([]gin.H, len(tickerErrs))
+		for i, tickerErr := range tickerErrs {
+			failures[i] = gin.H{"ticker": tickerErr.Ticker, "error": tickerErr.Err.Error()}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Recommendations generated with errors",
+			"data":    run,
+			"errors":  failures,
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to generate recommendations",
@@ -133,6 +417,7 @@ func (h *StockHandler) GenerateRecommendations(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Recommendations generated successfully",
+		"data":    run,
 	})
 }
 
@@ -140,11 +425,58 @@ func (h *StockHandler) GenerateRecommendations(c *gin.Context) {
 func (h *StockHandler) GetRecommendations(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 50 {
+	if err != nil || limit <= 0 || limit > 100 {
 		limit = 10
 	}
 
-	recommendations, err := h.stockService.GetTopRecommendations(limit)
+	offsetStr := c.DefaultQuery("offset", "0")
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	filter, ok := parseRecommendationFilter(c)
+	if !ok {
+		return
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+	if filter.After, ok = parseCursor(c, offset); !ok {
+		return
+	}
+	withTotal, ok := parseCount(c)
+	if !ok {
+		return
+	}
+
+	// Movers are ranked by their score change, which is not counted
+	page := &models.RecommendationPage{}
+	if c.Query("sort") == "movers" {
+		if filter.After != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "The cursor parameter is not supported with sort=movers",
+			})
+			return
+		}
+		days, ok := parseDays(c, 7)
+		if !ok {
+			return
+		}
+		page.Recommendations, err = h.stockService.GetTopMovers(days, filter)
+	} else {
+		// Without an order, score and return sort descending and risk ascending
+		if filter.Sort, ok = parseSort(c, models.RecommendationSortFields, "score", "return"); !ok {
+			return
+		}
+		page, err = h.stockService.GetTopRecommendations(filter, withTotal)
+	}
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid cursor parameter",
+			"details": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to retrieve recommendations",
@@ -154,11 +486,147 @@ func (h *StockHandler) GetRecommendations(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":  recommendations,
-		"count": len(recommendations),
+		"data":       page.Recommendations,
+		"pagination": pagination(limit, offset, page.Total, page.Next),
+	})
+}
+
+// parseRecommendationFilter reads the filters of a recommendation listing, writing a 400 response
+// when one is invalid
+func parseRecommendationFilter(c *gin.Context) (models.RecommendationFilter, bool) {
+	filter := models.RecommendationFilter{
+		Sector:    c.Query("sector"),
+		RiskLevel: strings.ToLower(c.Query("risk_level")),
+		Sentiment: strings.ToLower(c.Query("analyst_sentiment")),
+		Query:     strings.TrimSpace(c.Query("q")),
+	}
+
+	var ok bool
+	if filter.TimeHorizon, ok = parseTimeHorizon(c, models.TimeHorizonMedium); !ok {
+		return filter, false
+	}
+	if filter.RiskLevel != "" && !containsField(models.RiskLevels, filter.RiskLevel) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid risk_level parameter, expected " + strings.Join(models.RiskLevels, ", "),
+		})
+		return filter, false
+	}
+	if filter.Sentiment != "" && !containsField(models.AnalystSentiments, filter.Sentiment) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid analyst_sentiment parameter, expected " + strings.Join(models.AnalystSentiments, ", "),
+		})
+		return filter, false
+	}
+
+	if value := c.Query("min_confidence"); value != "" {
+		minConfidence, err := strconv.ParseFloat(value, 64)
+		if err != nil || minConfidence < 0 || minConfidence > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid min_confidence parameter, expected a number between 0 and 100",
+			})
+			return filter, false
+		}
+		filter.MinConfidence = minConfidence
+	}
+
+	minScore, ok := parseFloatQuery(c, "min_score")
+	if !ok {
+		return filter, false
+	}
+	if minScore != nil {
+		filter.MinScore = *minScore
+	}
+	if filter.MaxScore, ok = parseFloatQuery(c, "max_score"); !ok {
+		return filter, false
+	}
+	if minScore != nil && filter.MaxScore != nil && *filter.MaxScore < *minScore {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid score range, min_score must not exceed max_score",
+		})
+		return filter, false
+	}
+	if value := c.Query("min_return"); value != "" {
+		minReturn, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid min_return parameter, expected a number",
+			})
+			return filter, false
+		}
+		filter.MinReturn = &minReturn
+	}
+	return filter, true
+}
+
+// GetRecommendationHistory handles GET /api/stocks/:ticker/recommendation-history
+func (h *StockHandler) GetRecommendationHistory(c *gin.Context) {
+	ticker := c.Param("ticker")
+	if ticker == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Ticker parameter is required",
+		})
+		return
+	}
+
+	days, ok := parseDays(c, 90)
+	if !ok {
+		return
+	}
+
+	// Without a time_horizon every horizon's history is returned
+	horizon, ok := parseTimeHorizon(c, "")
+	if !ok {
+		return
+	}
+
+	history, err := h.stockService.GetRecommendationHistory(ticker, horizon, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve recommendation history",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  history,
+		"count": len(history),
 	})
 }
 
+// parseDays reads the days query parameter (1-365), writing a 400 response when it is invalid
+func parseDays(c *gin.Context, fallback int) (int, bool) {
+	daysStr := c.Query("days")
+	if daysStr == "" {
+		return fallback, true
+	}
+
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days <= 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid days parameter, expected an integer between 1 and 365",
+		})
+		return 0, false
+	}
+	return days, true
+}
+
+// parseTimeHorizon reads the time_horizon query parameter, writing a 400 response when it is invalid
+func parseTimeHorizon(c *gin.Context, fallback string) (string, bool) {
+	horizon := c.Query("time_horizon")
+	if horizon == "" {
+		return fallback, true
+	}
+
+	if !service.IsTimeHorizon(horizon) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid time_horizon parameter, expected short, medium or long",
+		})
+		return "", false
+	}
+	return horizon, true
+}
+
 // HealthCheck handles GET /api/health
 func (h *StockHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
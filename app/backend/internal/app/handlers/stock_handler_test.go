@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"truora-backend/internal/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testContext returns a gin context for a GET request with the given query parameters
+func testContext(query url.Values) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+	return c, recorder
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []models.SortKey
+		ok    bool
+	}{
+		{
+			name: "no sort keeps the listing's default",
+			ok:   true,
+		},
+		{
+			name:  "fields take their default direction",
+			query: url.Values{"sort": {"time,ticker"}},
+			want:  []models.SortKey{{Field: "time", Desc: true}, {Field: "ticker"}},
+			ok:    true,
+		},
+		{
+			name:  "one order applies to every field",
+			query: url.Values{"sort": {"time, ticker"}, "order": {"ASC"}},
+			want:  []models.SortKey{{Field: "time"}, {Field: "ticker"}},
+			ok:    true,
+		},
+		{
+			name:  "one order per field",
+			query: url.Values{"sort": {"ticker,target"}, "order": {"desc,asc"}},
+			want:  []models.SortKey{{Field: "ticker", Desc: true}, {Field: "target"}},
+			ok:    true,
+		},
+		{
+			name:  "unknown field",
+			query: url.Values{"sort": {"price"}},
+		},
+		{
+			name:  "repeated field",
+			query: url.Values{"sort": {"time,time"}},
+		},
+		{
+			name:  "empty field",
+			query: url.Values{"sort": {"time,"}},
+		},
+		{
+			name:  "order count differs from the fields",
+			query: url.Values{"sort": {"time,ticker"}, "order": {"asc,desc,asc"}},
+		},
+		{
+			name:  "unknown direction",
+			query: url.Values{"sort": {"time"}, "order": {"up"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder := testContext(tt.query)
+			got, ok := parseSort(c, models.StockSortFields, "time", "target", "upside")
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				if recorder.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sort = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	cursor := models.Cursor{
+		Sort:   []models.SortKey{{Field: "target", Desc: true}},
+		Values: []interface{}{nil},
+		ID:     7,
+	}
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name   string
+		query  url.Values
+		offset int
+		want   *models.Cursor
+		ok     bool
+	}{
+		{
+			name: "no cursor",
+			ok:   true,
+		},
+		{
+			name:  "cursor with a NULL value",
+			query: url.Values{"cursor": {cursor.Encode()}},
+			want:  &cursor,
+			ok:    true,
+		},
+		{
+			name:   "cursor combined with an offset",
+			query:  url.Values{"cursor": {cursor.Encode()}},
+			offset: 20,
+		},
+		{
+			name:  "not base64",
+			query: url.Values{"cursor": {"not a cursor!"}},
+		},
+		{
+			name:  "not JSON",
+			query: url.Values{"cursor": {encode("cursor")}},
+		},
+		{
+			name:  "missing id",
+			query: url.Values{"cursor": {encode(`{"s":[{"f":"time"}],"v":["2025-03-14T09:30:00Z"]}`)}},
+		},
+		{
+			name:  "values do not match the sort",
+			query: url.Values{"cursor": {encode(`{"s":[{"f":"time"}],"v":[],"id":7}`)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder := testContext(tt.query)
+			got, ok := parseCursor(c, tt.offset)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				if recorder.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cursor = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
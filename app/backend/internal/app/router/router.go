@@ -7,7 +7,10 @@ import (
 )
 
 // SetupRouter configures and returns the Gin router
-func SetupRouter(stockHandler *handlers.StockHandler) *gin.Engine {
+func SetupRouter(stockHandler *handlers.StockHandler, priceHandler *handlers.PriceHandler, backtestHandler *handlers.BacktestHandler,
+	brokerageHandler *handlers.BrokerageHandler, sectorHandler *handlers.SectorHandler,
+	portfolioHandler *handlers.PortfolioHandler, anomalyHandler *handlers.AnomalyHandler, statsHandler *handlers.StatsHandler,
+	adminHandler *handlers.AdminHandler) *gin.Engine {
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
 
@@ -27,17 +30,69 @@ func SetupRouter(stockHandler *handlers.StockHandler) *gin.Engine {
 		// Stock routes
 		stocks := v1.Group("/stocks")
 		{
-			stocks.GET("", stockHandler.GetStocks)                // GET /api/v1/stocks
-			stocks.GET("/:ticker", stockHandler.GetStockByTicker) // GET /api/v1/stocks/:ticker
-			stocks.POST("/fetch", stockHandler.FetchStocks)       // POST /api/v1/stocks/fetch
+			stocks.GET("", stockHandler.GetStocks)                                               // GET /api/v1/stocks
+			stocks.GET("/export", stockHandler.ExportStocks)                                     // GET /api/v1/stocks/export
+			stocks.GET("/:ticker", stockHandler.GetStockDetail)                                  // GET /api/v1/stocks/:ticker
+			stocks.GET("/:ticker/consensus", stockHandler.GetConsensus)                          // GET /api/v1/stocks/:ticker/consensus
+			stocks.GET("/:ticker/recommendation-history", stockHandler.GetRecommendationHistory) // GET /api/v1/stocks/:ticker/recommendation-history
+			stocks.GET("/:ticker/prices", priceHandler.GetPrices)                                // GET /api/v1/stocks/:ticker/prices
+			stocks.POST("/fetch", stockHandler.FetchStocks)                                      // POST /api/v1/stocks/fetch
 		}
 
 		// Recommendation routes
 		recommendations := v1.Group("/recommendations")
 		{
-			recommendations.GET("", stockHandler.GetRecommendations)             // GET /api/v1/recommendations
+			recommendations.GET("", stockHandler.GetRecommendations)                // GET /api/v1/recommendations
+			recommendations.GET("/export", stockHandler.ExportRecommendations)      // GET /api/v1/recommendations/export
 			recommendations.POST("/generate", stockHandler.GenerateRecommendations) // POST /api/v1/recommendations/generate
 		}
+
+		// Backtest routes
+		backtests := v1.Group("/backtests")
+		{
+			backtests.POST("", backtestHandler.RunBacktest) // POST /api/v1/backtests
+		}
+
+		// Brokerage routes
+		brokerages := v1.Group("/brokerages")
+		{
+			brokerages.GET("", brokerageHandler.GetBrokerages)              // GET /api/v1/brokerages
+			brokerages.GET("/leaderboard", brokerageHandler.GetLeaderboard) // GET /api/v1/brokerages/leaderboard
+			brokerages.GET("/:id", brokerageHandler.GetBrokerage)           // GET /api/v1/brokerages/:id
+		}
+
+		// Sector routes
+		sectors := v1.Group("/sectors")
+		{
+			sectors.GET("", sectorHandler.GetSectors)        // GET /api/v1/sectors
+			sectors.GET("/:sector", sectorHandler.GetSector) // GET /api/v1/sectors/:sector
+		}
+
+		// Portfolio routes
+		portfolios := v1.Group("/portfolios")
+		{
+			portfolios.GET("", portfolioHandler.GetPortfolios)         // GET /api/v1/portfolios
+			portfolios.GET("/:id", portfolioHandler.GetPortfolio)      // GET /api/v1/portfolios/:id
+			portfolios.POST("/build", portfolioHandler.BuildPortfolio) // POST /api/v1/portfolios/build
+		}
+
+		// Anomaly routes
+		anomalies := v1.Group("/anomalies")
+		{
+			anomalies.GET("", anomalyHandler.GetAnomalies) // GET /api/v1/anomalies
+		}
+
+		// Stats routes
+		stats := v1.Group("/stats")
+		{
+			stats.GET("/overview", statsHandler.GetOverview) // GET /api/v1/stats/overview
+		}
+
+		// Admin routes
+		admin := v1.Group("/admin", adminHandler.Authorize)
+		{
+			admin.POST("/exports/parquet", adminHandler.ExportParquet) // POST /api/v1/admin/exports/parquet
+		}
 	}
 
 	return r
@@ -0,0 +1,48 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvFlushRows is how many rows the CSV encoder buffers before flushing them to the writer
+const csvFlushRows = 500
+
+type csvEncoder struct {
+	w       *csv.Writer
+	pending int
+	record  []string
+}
+
+// newCSVEncoder creates an encoder writing a header line and comma-separated rows
+func newCSVEncoder(w io.Writer, columns []string) (Encoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	if err := e.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// WriteRow writes one record
+func (e *csvEncoder) WriteRow(values []interface{}) error {
+	for i, value := range values {
+		e.record[i] = formatCell(value)
+	}
+	if err := e.w.Write(e.record); err != nil {
+		return err
+	}
+
+	e.pending++
+	if e.pending >= csvFlushRows {
+		e.pending = 0
+		e.w.Flush()
+		return e.w.Error()
+	}
+	return nil
+}
+
+// Close flushes the buffered rows
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Encoder writes a table one row at a time. Values are strings, integers, float64, bool,
+// time.Time, or pointers to them where nil is an empty cell.
+type Encoder interface {
+	WriteRow(values []interface{}) error
+	// Close writes what the format needs after the last row; it does not close the writer
+	Close() error
+}
+
+// NewEncoder creates an encoder of a format writing a table with columns to w
+func NewEncoder(format Format, w io.Writer, columns []string) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVEncoder(w, columns)
+	case FormatNDJSON:
+		return newNDJSONEncoder(w, columns), nil
+	case FormatXLSX:
+		return newXLSXEncoder(w, columns)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// deref returns the value a pointer cell points to, or nil for a nil pointer
+func deref(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	return value
+}
+
+// formatCell renders a cell as text; nil is empty
+func formatCell(value interface{}) string {
+	switch v := deref(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Format is an export file format
+type Format string
+
+// Export formats
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatXLSX   Format = "xlsx"
+)
+
+// Formats lists the supported export formats, the default first
+var Formats = []Format{FormatCSV, FormatNDJSON, FormatXLSX}
+
+// contentTypes maps each format to its media type
+var contentTypes = map[Format]string{
+	FormatCSV:    "text/csv",
+	FormatNDJSON: "application/x-ndjson",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// mediaTypeAliases are further media types accepted for a format
+var mediaTypeAliases = map[string]Format{
+	"application/ndjson":   FormatNDJSON,
+	"application/jsonl":    FormatNDJSON,
+	"application/json-seq": FormatNDJSON,
+}
+
+// ParseFormat returns the format with a name
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := contentTypes[format]; !ok {
+		return "", fmt.Errorf("unsupported export format %q", name)
+	}
+	return format, nil
+}
+
+// FormatFromAccept returns the first format an Accept header lists, in the header's order, and
+// false when it lists none of them. Quality values are ignored.
+func FormatFromAccept(accept string) (Format, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		for format, contentType := range contentTypes {
+			if mediaType == contentType {
+				return format, true
+			}
+		}
+		if format, ok := mediaTypeAliases[mediaType]; ok {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// ContentType returns the format's media type
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// Extension returns the format's file extension, without the dot
+func (f Format) Extension() string {
+	return string(f)
+}
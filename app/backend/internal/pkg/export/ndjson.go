@@ -0,0 +1,48 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+type ndjsonEncoder struct {
+	w       *bufio.Writer
+	columns [][]byte // column names as JSON strings
+}
+
+// newNDJSONEncoder creates an encoder writing one JSON object per line, keyed by column in order
+func newNDJSONEncoder(w io.Writer, columns []string) Encoder {
+	e := &ndjsonEncoder{w: bufio.NewWriter(w)}
+	for _, column := range columns {
+		key, _ := json.Marshal(column)
+		e.columns = append(e.columns, key)
+	}
+	return e
+}
+
+// WriteRow writes one object
+func (e *ndjsonEncoder) WriteRow(values []interface{}) error {
+	e.w.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		e.w.Write(e.columns[i])
+		e.w.WriteByte(':')
+
+		encoded, err := json.Marshal(deref(value))
+		if err != nil {
+			return err
+		}
+		e.w.Write(encoded)
+	}
+	e.w.WriteByte('}')
+	_, err := e.w.WriteString("\n")
+	return err
+}
+
+// Close flushes the buffered lines
+func (e *ndjsonEncoder) Close() error {
+	return e.w.Flush()
+}
@@ -0,0 +1,506 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+	"truora-backend/internal/pkg/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Parquet datasets, each written to its own file or month-partitioned directory
+const (
+	DatasetStocks             = "stocks"
+	DatasetRecommendations    = "recommendations"
+	DatasetRecommendationRuns = "recommendation_runs"
+)
+
+// parquetBatchSize is the number of rows buffered before they are handed to the Parquet writer
+const parquetBatchSize = 1024
+
+// ParquetStock is an analyst event as written to Parquet, typed by parquetStockSchema
+type ParquetStock struct {
+	ID              int64     `parquet:"id"`
+	Ticker          string    `parquet:"ticker"`
+	Company         string    `parquet:"company"`
+	Brokerage       string    `parquet:"brokerage"`
+	Action          string    `parquet:"action"`
+	RatingFrom      string    `parquet:"rating_from"`
+	RatingTo        string    `parquet:"rating_to"`
+	TargetFrom      string    `parquet:"target_from"`
+	TargetTo        string    `parquet:"target_to"`
+	TargetFromValue *int64    `parquet:"target_from_value"`
+	TargetToValue   *int64    `parquet:"target_to_value"`
+	Time            time.Time `parquet:"time"`
+	LastUpdated     time.Time `parquet:"last_updated"`
+}
+
+// parquetStockSchema types analyst events: targets are decimal(12,2) like their database
+// columns and times are UTC timestamps
+var parquetStockSchema = parquet.NewSchema("stock", parquet.Group{
+	"id":                parquet.Int(64),
+	"ticker":            parquet.String(),
+	"company":           parquet.String(),
+	"brokerage":         parquet.String(),
+	"action":            parquet.String(),
+	"rating_from":       parquet.String(),
+	"rating_to":         parquet.String(),
+	"target_from":       parquet.String(),
+	"target_to":         parquet.String(),
+	"target_from_value": parquet.Optional(decimalNode(2, 12)),
+	"target_to_value":   parquet.Optional(decimalNode(2, 12)),
+	"time":              timestampNode(),
+	"last_updated":      timestampNode(),
+})
+
+// ParquetStockRow converts an analyst event to its Parquet row
+func ParquetStockRow(stock models.Stock) ParquetStock {
+	return ParquetStock{
+		ID:              int64(stock.ID),
+		Ticker:          stock.Ticker,
+		Company:         stock.Company,
+		Brokerage:       stock.Brokerage,
+		Action:          stock.Action,
+		RatingFrom:      stock.RatingFrom,
+		RatingTo:        stock.RatingTo,
+		TargetFrom:      stock.TargetFrom,
+		TargetTo:        stock.TargetTo,
+		TargetFromValue: optionalDecimal(stock.TargetFromValue, 2),
+		TargetToValue:   optionalDecimal(stock.TargetToValue, 2),
+		Time:            stock.Time.UTC(),
+		LastUpdated:     stock.LastUpdated.UTC(),
+	}
+}
+
+// ParquetRecommendation is a recommendation as written to Parquet, typed by
+// parquetRecommendationSchema
+type ParquetRecommendation struct {
+	ID                  int64     `parquet:"id"`
+	StockID             int64     `parquet:"stock_id"`
+	Ticker              string    `parquet:"ticker"`
+	TimeHorizon         string    `parquet:"time_horizon"`
+	RecommendationScore int64     `parquet:"recommendation_score"`
+	ScoreLower          int64     `parquet:"score_lower"`
+	ScoreUpper          int64     `parquet:"score_upper"`
+	Confidence          int64     `parquet:"confidence"`
+	Percentile          *int64    `parquet:"percentile"`
+	ZScore              *int64    `parquet:"z_score"`
+	Grade               *string   `parquet:"grade"`
+	RiskLevel           string    `parquet:"risk_level"`
+	RiskScore           int64     `parquet:"risk_score"`
+	ExpectedReturn      int64     `parquet:"expected_return"`
+	ExpectedReturnLow   int64     `parquet:"expected_return_low"`
+	ExpectedReturnHigh  int64     `parquet:"expected_return_high"`
+	Upside              *int64    `parquet:"upside"`
+	ReferencePrice      *int64    `parquet:"reference_price"`
+	TargetCount         int32     `parquet:"target_count"`
+	TargetMean          int64     `parquet:"target_mean"`
+	TargetMedian        int64     `parquet:"target_median"`
+	TargetHigh          int64     `parquet:"target_high"`
+	TargetLow           int64     `parquet:"target_low"`
+	TargetChange        int64     `parquet:"target_change"`
+	AnalystSentiment    string    `parquet:"analyst_sentiment"`
+	ConsensusRating     string    `parquet:"consensus_rating"`
+	BrokerageCount      int32     `parquet:"brokerage_count"`
+	UpgradeCount        int32     `parquet:"upgrade_count"`
+	DowngradeCount      int32     `parquet:"downgrade_count"`
+	Reason              string    `parquet:"reason"`
+	CreatedAt           time.Time `parquet:"created_at"`
+	UpdatedAt           time.Time `parquet:"updated_at"`
+}
+
+// parquetRecommendationSchema types recommendations with the decimal precision of their
+// database columns
+var parquetRecommendationSchema = parquet.NewSchema("recommendation", parquet.Group{
+	"id":                   parquet.Int(64),
+	"stock_id":             parquet.Int(64),
+	"ticker":               parquet.String(),
+	"time_horizon":         parquet.String(),
+	"recommendation_score": decimalNode(2, 5),
+	"score_lower":          decimalNode(2, 5),
+	"score_upper":          decimalNode(2, 5),
+	"confidence":           decimalNode(2, 5),
+	"percentile":           parquet.Optional(decimalNode(2, 5)),
+	"z_score":              parquet.Optional(decimalNode(3, 6)),
+	"grade":                parquet.Optional(parquet.String()),
+	"risk_level":           parquet.String(),
+	"risk_score":           decimalNode(2, 5),
+	"expected_return":      decimalNode(2, 5),
+	"expected_return_low":  decimalNode(2, 5),
+	"expected_return_high": decimalNode(2, 5),
+	"upside":               parquet.Optional(decimalNode(2, 5)),
+	"reference_price":      parquet.Optional(decimalNode(4, 12)),
+	"target_count":         parquet.Int(32),
+	"target_mean":          decimalNode(2, 12),
+	"target_median":        decimalNode(2, 12),
+	"target_high":          decimalNode(2, 12),
+	"target_low":           decimalNode(2, 12),
+	"target_change":        decimalNode(2, 5),
+	"analyst_sentiment":    parquet.String(),
+	"consensus_rating":     parquet.String(),
+	"brokerage_count":      parquet.Int(32),
+	"upgrade_count":        parquet.Int(32),
+	"downgrade_count":      parquet.Int(32),
+	"reason":               parquet.String(),
+	"created_at":           timestampNode(),
+	"updated_at":           timestampNode(),
+})
+
+// ParquetRecommendationRow converts a recommendation to its Parquet row
+func ParquetRecommendationRow(r models.StockRecommendation) ParquetRecommendation {
+	return ParquetRecommendation{
+		ID:                  int64(r.ID),
+		StockID:             int64(r.StockID),
+		Ticker:              r.Ticker,
+		TimeHorizon:         r.TimeHorizon,
+		RecommendationScore: decimal(r.RecommendationScore, 2),
+		ScoreLower:          decimal(r.ScoreLower, 2),
+		ScoreUpper:          decimal(r.ScoreUpper, 2),
+		Confidence:          decimal(r.Confidence, 2),
+		Percentile:          optionalDecimal(r.Percentile, 2),
+		ZScore:              optionalDecimal(r.ZScore, 3),
+		Grade:               r.Grade,
+		RiskLevel:           r.RiskLevel,
+		RiskScore:           decimal(r.RiskScore, 2),
+		ExpectedReturn:      decimal(r.ExpectedReturn, 2),
+		ExpectedReturnLow:   decimal(r.ExpectedReturnLow, 2),
+		ExpectedReturnHigh:  decimal(r.ExpectedReturnHigh, 2),
+		Upside:              optionalDecimal(r.Upside, 2),
+		ReferencePrice:      optionalDecimal(r.ReferencePrice, 4),
+		TargetCount:         int32(r.TargetCount),
+		TargetMean:          decimal(r.TargetMean, 2),
+		TargetMedian:        decimal(r.TargetMedian, 2),
+		TargetHigh:          decimal(r.TargetHigh, 2),
+		TargetLow:           decimal(r.TargetLow, 2),
+		TargetChange:        decimal(r.TargetChange, 2),
+		AnalystSentiment:    r.AnalystSentiment,
+		ConsensusRating:     r.ConsensusRating,
+		BrokerageCount:      int32(r.BrokerageCount),
+		UpgradeCount:        int32(r.UpgradeCount),
+		DowngradeCount:      int32(r.DowngradeCount),
+		Reason:              r.Reason,
+		CreatedAt:           r.CreatedAt.UTC(),
+		UpdatedAt:           r.UpdatedAt.UTC(),
+	}
+}
+
+// ParquetRecommendationRun is a recommendation engine run as written to Parquet, typed by
+// parquetRecommendationRunSchema
+type ParquetRecommendationRun struct {
+	ID          int64      `parquet:"id"`
+	Full        bool       `parquet:"full"`
+	Since       *time.Time `parquet:"since"`
+	Status      string     `parquet:"status"`
+	TickerCount int32      `parquet:"ticker_count"`
+	ErrorCount  int32      `parquet:"error_count"`
+	Error       *string    `parquet:"error"`
+	StartedAt   time.Time  `parquet:"started_at"`
+	FinishedAt  *time.Time `parquet:"finished_at"`
+}
+
+// parquetRecommendationRunSchema types recommendation runs; runs still in progress have no
+// finish time
+var parquetRecommendationRunSchema = parquet.NewSchema("recommendation_run", parquet.Group{
+	"id":           parquet.Int(64),
+	"full":         parquet.Leaf(parquet.BooleanType),
+	"since":        parquet.Optional(timestampNode()),
+	"status":       parquet.String(),
+	"ticker_count": parquet.Int(32),
+	"error_count":  parquet.Int(32),
+	"error":        parquet.Optional(parquet.String()),
+	"started_at":   timestampNode(),
+	"finished_at":  parquet.Optional(timestampNode()),
+})
+
+// ParquetRecommendationRunRow converts a recommendation run to its Parquet row
+func ParquetRecommendationRunRow(run models.RecommendationRun) ParquetRecommendationRun {
+	row := ParquetRecommendationRun{
+		ID:          int64(run.ID),
+		Full:        run.Full,
+		Since:       optionalUTC(run.Since),
+		Status:      run.Status,
+		TickerCount: int32(run.TickerCount),
+		ErrorCount:  int32(run.ErrorCount),
+		StartedAt:   run.StartedAt.UTC(),
+		FinishedAt:  optionalUTC(run.FinishedAt),
+	}
+	if run.Error != "" {
+		row.Error = &run.Error
+	}
+	return row
+}
+
+// decimalNode is a decimal column stored as an unscaled int64
+func decimalNode(scale, precision int) parquet.Node {
+	return parquet.Decimal(scale, precision, parquet.Int64Type)
+}
+
+// timestampNode is a UTC timestamp column with microsecond precision
+func timestampNode() parquet.Node {
+	return parquet.Timestamp(parquet.Microsecond)
+}
+
+// decimal returns a value as an unscaled decimal with scale digits after the point
+func decimal(value float64, scale int) int64 {
+	return int64(math.Round(value * math.Pow10(scale)))
+}
+
+// optionalDecimal returns a value as an unscaled decimal, or nil for a nil value
+func optionalDecimal(value *float64, scale int) *int64 {
+	if value == nil {
+		return nil
+	}
+	unscaled := decimal(*value, scale)
+	return &unscaled
+}
+
+// optionalUTC returns a time in UTC, or nil for a nil time
+func optionalUTC(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	utc := value.UTC()
+	return &utc
+}
+
+// ParquetFile describes one file written by a Parquet export
+type ParquetFile struct {
+	Dataset string `json:"dataset"`
+	Month   string `json:"month,omitempty"` // YYYY-MM partition, empty when unpartitioned
+	Path    string `json:"path"`
+	Rows    int64  `json:"rows"`
+}
+
+// ParquetExport stages the datasets of one export in a hidden directory under the export
+// directory and swaps them all into place on Publish. The previous output of a dataset is moved
+// aside rather than removed, and only deleted once every new dataset is in place, so a failed
+// export or a crash never loses the previous one.
+type ParquetExport struct {
+	dir      string
+	staging  string
+	datasets []string
+}
+
+// NewParquetExport creates an export under dir along with its staging directory
+func NewParquetExport(dir string) (*ParquetExport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(dir, ".export-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export staging directory: %w", err)
+	}
+	return &ParquetExport{dir: dir, staging: staging}, nil
+}
+
+// Publish replaces the previous output of every closed dataset, in either layout so switching
+// modes never leaves stale files behind, with its staged output. The previous outputs are moved
+// into the staging directory first and restored if any staged output cannot be moved into place.
+func (e *ParquetExport) Publish() error {
+	previous := filepath.Join(e.staging, ".previous")
+	if err := os.Mkdir(previous, 0o755); err != nil {
+		return fmt.Errorf("failed to create export staging directory: %w", err)
+	}
+
+	var outputs []string
+	for _, dataset := range e.datasets {
+		outputs = append(outputs, dataset+".parquet", dataset)
+	}
+	var movedAside, published []string
+	rollback := func() {
+		for _, output := range published {
+			os.RemoveAll(filepath.Join(e.dir, output))
+		}
+		for _, output := range movedAside {
+			os.Rename(filepath.Join(previous, output), filepath.Join(e.dir, output))
+		}
+	}
+
+	for _, output := range outputs {
+		err := os.Rename(filepath.Join(e.dir, output), filepath.Join(previous, output))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to move previous %s export aside: %w", output, err)
+		}
+		movedAside = append(movedAside, output)
+	}
+	for _, output := range outputs {
+		err := os.Rename(filepath.Join(e.staging, output), filepath.Join(e.dir, output))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to move %s export into place: %w", output, err)
+		}
+		published = append(published, output)
+	}
+
+	if err := os.RemoveAll(e.staging); err != nil {
+		return fmt.Errorf("failed to remove export staging directory: %w", err)
+	}
+	return nil
+}
+
+// Abort removes every staged file after a failed export, leaving the previous output in place
+func (e *ParquetExport) Abort() {
+	os.RemoveAll(e.staging)
+}
+
+// ParquetWriter writes the rows of one dataset of an export, either to <dataset>.parquet or,
+// partitioned by month, to hive-style <dataset>/month=YYYY-MM/part-0.parquet files. Partitioned
+// rows must arrive grouped by month; a month that comes back after another is an error. Files are
+// written to the export's staging directory and only replace the dataset's previous output when
+// the export is published.
+type ParquetWriter[T any] struct {
+	export      *ParquetExport
+	dataset     string
+	schema      *parquet.Schema
+	partitioned bool
+
+	file    *os.File
+	writer  *parquet.GenericWriter[T]
+	batch   []T
+	current ParquetFile
+	written map[string]bool
+	files   []ParquetFile
+}
+
+// NewStockParquetWriter creates a writer of the analyst events dataset of an export
+func NewStockParquetWriter(export *ParquetExport, partitioned bool) *ParquetWriter[ParquetStock] {
+	return newParquetWriter[ParquetStock](export, DatasetStocks, parquetStockSchema, partitioned)
+}
+
+// NewRecommendationParquetWriter creates a writer of the recommendations dataset of an export
+func NewRecommendationParquetWriter(export *ParquetExport, partitioned bool) *ParquetWriter[ParquetRecommendation] {
+	return newParquetWriter[ParquetRecommendation](export, DatasetRecommendations, parquetRecommendationSchema, partitioned)
+}
+
+// NewRecommendationRunParquetWriter creates a writer of the recommendation runs dataset of an export
+func NewRecommendationRunParquetWriter(export *ParquetExport, partitioned bool) *ParquetWriter[ParquetRecommendationRun] {
+	return newParquetWriter[ParquetRecommendationRun](export, DatasetRecommendationRuns, parquetRecommendationRunSchema, partitioned)
+}
+
+// newParquetWriter creates a writer of a dataset with a schema in an export
+func newParquetWriter[T any](export *ParquetExport, dataset string, schema *parquet.Schema, partitioned bool) *ParquetWriter[T] {
+	return &ParquetWriter[T]{
+		export:      export,
+		dataset:     dataset,
+		schema:      schema,
+		partitioned: partitioned,
+		written:     make(map[string]bool),
+	}
+}
+
+// Write adds a row at a time, which picks its month partition when the writer is partitioned
+func (w *ParquetWriter[T]) Write(row T, at time.Time) error {
+	month := ""
+	if w.partitioned {
+		month = at.UTC().Format("2006-01")
+	}
+	if w.file == nil || month != w.current.Month {
+		if err := w.closeFile(); err != nil {
+			return err
+		}
+		if err := w.openFile(month); err != nil {
+			return err
+		}
+	}
+
+	w.batch = append(w.batch, row)
+	w.current.Rows++
+	if len(w.batch) >= parquetBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close finishes the open file, adds the dataset to those its export publishes and returns every
+// file written. An unpartitioned dataset without rows still gets a file carrying its schema; a
+// partitioned one leaves no files.
+func (w *ParquetWriter[T]) Close() ([]ParquetFile, error) {
+	if w.file == nil && !w.partitioned && len(w.files) == 0 {
+		if err := w.openFile(""); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.closeFile(); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	w.export.datasets = append(w.export.datasets, w.dataset)
+	return w.files, nil
+}
+
+// Abort closes the open file after a failed write; the export's Abort removes the staged files
+func (w *ParquetWriter[T]) Abort() {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+}
+
+// openFile creates the file of a month partition, or the dataset's only file for an empty month
+func (w *ParquetWriter[T]) openFile(month string) error {
+	if w.written[month] {
+		return fmt.Errorf("rows of %s month %s are not contiguous", w.dataset, month)
+	}
+
+	rel := w.dataset + ".parquet"
+	if month != "" {
+		rel = filepath.Join(w.dataset, "month="+month, "part-0.parquet")
+	}
+	staged := filepath.Join(w.export.staging, rel)
+	if err := os.MkdirAll(filepath.Dir(staged), 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	file, err := os.Create(staged)
+	if err != nil {
+		return fmt.Errorf("failed to create parquet file: %w", err)
+	}
+
+	w.file = file
+	w.writer = parquet.NewGenericWriter[T](file, w.schema)
+	w.current = ParquetFile{Dataset: w.dataset, Month: month, Path: filepath.Join(w.export.dir, rel)}
+	w.written[month] = true
+	return nil
+}
+
+// flush hands the buffered rows to the Parquet writer
+func (w *ParquetWriter[T]) flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+	if _, err := w.writer.Write(w.batch); err != nil {
+		return fmt.Errorf("failed to write %s rows: %w", w.dataset, err)
+	}
+	w.batch = w.batch[:0]
+	return nil
+}
+
+// closeFile writes the open file's footer and records it among the written files
+func (w *ParquetWriter[T]) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	if err := w.writer.Close(); err != nil {
+		return fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close parquet file: %w", err)
+	}
+	w.files = append(w.files, w.current)
+	w.file = nil
+	return nil
+}
@@ -0,0 +1,37 @@
+package export
+
+import "truora-backend/internal/pkg/models"
+
+// StockColumns are the columns of an analyst event export
+var StockColumns = []string{
+	"id", "ticker", "company", "brokerage", "action", "rating_from", "rating_to",
+	"target_from", "target_to", "target_from_value", "target_to_value", "upside", "time",
+}
+
+// StockRow returns an analyst event's cells in StockColumns order
+func StockRow(stock models.Stock) []interface{} {
+	return []interface{}{
+		stock.ID, stock.Ticker, stock.Company, stock.Brokerage, stock.Action, stock.RatingFrom, stock.RatingTo,
+		stock.TargetFrom, stock.TargetTo, stock.TargetFromValue, stock.TargetToValue, stock.Upside, stock.Time,
+	}
+}
+
+// RecommendationColumns are the columns of a recommendation export
+var RecommendationColumns = []string{
+	"id", "ticker", "time_horizon", "recommendation_score", "score_lower", "score_upper", "confidence",
+	"percentile", "z_score", "grade", "risk_level", "risk_score", "expected_return", "expected_return_low",
+	"expected_return_high", "upside", "reference_price", "target_count", "target_mean", "target_median",
+	"target_high", "target_low", "analyst_sentiment", "consensus_rating", "brokerage_count",
+	"upgrade_count", "downgrade_count", "reason", "updated_at",
+}
+
+// RecommendationRow returns a recommendation's cells in RecommendationColumns order
+func RecommendationRow(r models.StockRecommendation) []interface{} {
+	return []interface{}{
+		r.ID, r.Ticker, r.TimeHorizon, r.RecommendationScore, r.ScoreLower, r.ScoreUpper, r.Confidence,
+		r.Percentile, r.ZScore, r.Grade, r.RiskLevel, r.RiskScore, r.ExpectedReturn, r.ExpectedReturnLow,
+		r.ExpectedReturnHigh, r.Upside, r.ReferencePrice, r.TargetCount, r.TargetMean, r.TargetMedian,
+		r.TargetHigh, r.TargetLow, r.AnalystSentiment, r.ConsensusRating, r.BrokerageCount,
+		r.UpgradeCount, r.DowngradeCount, r.Reason, r.UpdatedAt,
+	}
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+// xlsxParts are the workbook parts written before the sheet: one workbook holding one sheet
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+type xlsxEncoder struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	row     int
+	columns []string // column letters
+	created time.Time
+}
+
+// newXLSXEncoder creates an encoder writing a single-sheet workbook. The sheet is the last part
+// of the archive, so rows stream into it as they are written; text and times are inline strings.
+func newXLSXEncoder(w io.Writer, columns []string) (Encoder, error) {
+	e := &xlsxEncoder{zip: zip.NewWriter(w), created: time.Now()}
+	for _, part := range xlsxParts {
+		f, err := e.create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := e.create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	e.sheet = bufio.NewWriter(f)
+	e.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		e.columns = append(e.columns, columnName(i))
+		header[i] = column
+	}
+	return e, e.WriteRow(header)
+}
+
+// WriteRow writes one sheet row
+func (e *xlsxEncoder) WriteRow(values []interface{}) error {
+	e.row++
+	row := strconv.Itoa(e.row)
+	e.sheet.WriteString(`<row r="` + row + `">`)
+	for i, value := range values {
+		ref := e.columns[i] + row
+		switch v := deref(value).(type) {
+		case nil:
+			continue
+		case float64:
+			e.sheet.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatFloat(v, 'f', -1, 64) + `</v></c>`)
+		case int, int64, uint:
+			e.sheet.WriteString(`<c r="` + ref + `"><v>` + formatCell(v) + `</v></c>`)
+		case bool:
+			flag := "0"
+			if v {
+				flag = "1"
+			}
+			e.sheet.WriteString(`<c r="` + ref + `" t="b"><v>` + flag + `</v></c>`)
+		case time.Time:
+			e.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t>` + formatCell(v) + `</t></is></c>`)
+		default:
+			e.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(e.sheet, []byte(formatCell(v))); err != nil {
+				return err
+			}
+			e.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := e.sheet.WriteString(`</row>`)
+	return err
+}
+
+// create starts a compressed archive entry stamped with the export time
+func (e *xlsxEncoder) create(name string) (io.Writer, error) {
+	return e.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: e.created})
+}
+
+// Close ends the sheet and writes the archive directory
+func (e *xlsxEncoder) Close() error {
+	e.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := e.sheet.Flush(); err != nil {
+		return err
+	}
+	return e.zip.Close()
+}
+
+// columnName returns the spreadsheet letters of a zero-based column index: A, B, ..., Z, AA, ...
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Anomaly is a burst of unusual analyst activity on a ticker
+type Anomaly struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Ticker      string    `json:"ticker" gorm:"not null;size:10;uniqueIndex:idx_anomalies_key,priority:1"`
+	Type        string    `json:"type" gorm:"not null;size:40;uniqueIndex:idx_anomalies_key,priority:2;index"`
+	Severity    string    `json:"severity" gorm:"not null;size:20;index"`
+	Description string    `json:"description" gorm:"type:text"`
+	Brokerages  string    `json:"brokerages" gorm:"type:text"` // comma-separated
+	EventCount  int       `json:"event_count"`
+	Magnitude   *float64  `json:"magnitude" gorm:"type:decimal(7,2)"` // e.g. the % target cut
+	WindowStart time.Time `json:"window_start" gorm:"not null;uniqueIndex:idx_anomalies_key,priority:3"`
+	WindowEnd   time.Time `json:"window_end" gorm:"not null;index"`
+	DetectedAt  time.Time `json:"detected_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Anomaly types
+const (
+	AnomalyDowngradeBurst     = "downgrade_burst"
+	AnomalyTargetCut          = "target_cut"
+	AnomalyCoverageInitiation = "coverage_initiation"
+	AnomalyRatingReversal     = "rating_reversal"
+)
+
+// Anomaly severities
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// AnomalyFilter narrows an anomaly listing
+type AnomalyFilter struct {
+	Ticker   string
+	Type     string
+	Severity string
+	Since    time.Time // only anomalies whose window ends at or after Since
+	Limit    int
+}
+
+// TableName sets the table name for Anomaly
+func (Anomaly) TableName() string {
+	return "anomalies"
+}
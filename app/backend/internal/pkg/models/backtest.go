@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// BacktestConfig describes a replay of historical analyst events
+type BacktestConfig struct {
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	StepDays     int       `json:"step_days"`
+	HorizonDays  []int     `json:"horizon_days"`
+	Strategy     string    `json:"strategy"`
+	TopN         int       `json:"top_n"`
+	MinScore     *float64  `json:"min_score,omitempty"` // nil defaults to 60 for the threshold strategy
+	Profile      string    `json:"profile"`
+	LookbackDays int       `json:"lookback_days"`
+	Benchmark    string    `json:"benchmark"`
+}
+
+// Backtest strategies
+const (
+	StrategyTopN      = "top_n"
+	StrategyThreshold = "threshold"
+)
+
+// BacktestResult summarizes how well a strategy's picks predicted forward returns
+type BacktestResult struct {
+	Config      BacktestConfig          `json:"config"`
+	Dates       int                     `json:"dates"`
+	Tickers     int                     `json:"tickers"`
+	Horizons    []BacktestHorizonResult `json:"horizons"`
+	GeneratedAt time.Time               `json:"generated_at"`
+}
+
+// BacktestHorizonResult holds the metrics for one forward-return horizon
+type BacktestHorizonResult struct {
+	HorizonDays       int                    `json:"horizon_days"`
+	Picks             int                    `json:"picks"`
+	HitRate           float64                `json:"hit_rate"`
+	AvgReturn         float64                `json:"avg_return"`
+	BenchmarkReturn   float64                `json:"benchmark_return"`
+	ExcessReturn      float64                `json:"excess_return"`
+	BeatBenchmarkRate float64                `json:"beat_benchmark_rate"`
+	Deciles           []BacktestDecileResult `json:"deciles"`
+	Periods           []BacktestPeriodResult `json:"periods"`
+}
+
+// BacktestDecileResult is the average forward return of one score decile (1 = lowest scores)
+type BacktestDecileResult struct {
+	Decile    int     `json:"decile"`
+	MinScore  float64 `json:"min_score"`
+	MaxScore  float64 `json:"max_score"`
+	Count     int     `json:"count"`
+	AvgReturn float64 `json:"avg_return"`
+}
+
+// BacktestPeriodResult is the outcome of the picks made on one rebalance date
+type BacktestPeriodResult struct {
+	Date            time.Time `json:"date"`
+	Picks           int       `json:"picks"`
+	AvgReturn       float64   `json:"avg_return"`
+	BenchmarkReturn float64   `json:"benchmark_return"`
+}
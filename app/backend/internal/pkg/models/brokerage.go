@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// BrokerageScore is a brokerage's evaluated track record and the credibility weight derived from it
+type BrokerageScore struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Brokerage      string    `json:"brokerage" gorm:"not null;size:255;uniqueIndex"`
+	Calls          int       `json:"calls" gorm:"not null;default:0"`
+	Hits           int       `json:"hits" gorm:"not null;default:0"`
+	HitRate        float64   `json:"hit_rate" gorm:"type:decimal(5,2)"`
+	PriceCalls     int       `json:"price_calls" gorm:"default:0"`
+	ConsensusCalls int       `json:"consensus_calls" gorm:"default:0"`
+	AvgReturn      *float64  `json:"avg_return" gorm:"type:decimal(7,2)"`
+	Credibility    float64   `json:"credibility" gorm:"not null;type:decimal(5,4);index"`
+	EvaluatedAt    time.Time `json:"evaluated_at" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for BrokerageScore
+func (BrokerageScore) TableName() string {
+	return "brokerage_scores"
+}
+
+// BrokerageSummary is a brokerage's entry in the directory, built from its analyst events
+type BrokerageSummary struct {
+	ID            string    `json:"id" gorm:"-"` // URL slug of the name
+	Name          string    `json:"name"`
+	EventCount    int       `json:"event_count"`
+	TickerCount   int       `json:"ticker_count"`
+	FirstActivity time.Time `json:"first_activity"`
+	LastActivity  time.Time `json:"last_activity"`
+	Credibility   *float64  `json:"credibility"` // unset until the track record is evaluated
+}
+
+// BrokerageCoverage is a ticker a brokerage covers with its latest rating and target on it
+type BrokerageCoverage struct {
+	Ticker       string    `json:"ticker"`
+	Company      string    `json:"company"`
+	RatingTo     string    `json:"rating"`
+	TargetTo     string    `json:"target"`
+	Action       string    `json:"action"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// BrokerageActivity counts a brokerage's rating changes and averages its target changes
+type BrokerageActivity struct {
+	UpgradeCount    int      `json:"upgrade_count"`
+	DowngradeCount  int      `json:"downgrade_count"`
+	UpgradeRatio    *float64 `json:"upgrade_ratio"`     // upgrades per downgrade, unset without downgrades
+	AvgTargetChange *float64 `json:"avg_target_change"` // percent, over events with both targets
+}
+
+// BrokerageProfile is a brokerage's directory entry with its coverage, activity and recent actions
+type BrokerageProfile struct {
+	BrokerageSummary
+	BrokerageActivity
+	TrackRecord    *BrokerageScore     `json:"track_record"`
+	CoveredTickers []BrokerageCoverage `json:"covered_tickers"`
+	RecentActions  []Stock             `json:"recent_actions"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// RatingDistribution counts brokerages by their current rating bucket
+type RatingDistribution struct {
+	StrongBuy  int `json:"strong_buy"`
+	Buy        int `json:"buy"`
+	Hold       int `json:"hold"`
+	Sell       int `json:"sell"`
+	StrongSell int `json:"strong_sell"`
+	Unrated    int `json:"unrated"`
+}
+
+// Consensus is the street's current view of a ticker, built from each
+// brokerage's most recent rating and price target
+type Consensus struct {
+	Ticker             string             `json:"ticker"`
+	Company            string             `json:"company"`
+	ConsensusRating    string             `json:"consensus_rating"`
+	ConsensusScore     float64            `json:"consensus_score"`
+	RatingDistribution RatingDistribution `json:"rating_distribution"`
+	TargetCount        int                `json:"target_count"`
+	TargetMean         float64            `json:"target_mean"`
+	TargetMedian       float64            `json:"target_median"`
+	TargetHigh         float64            `json:"target_high"`
+	TargetLow          float64            `json:"target_low"`
+	ReferencePrice     *float64           `json:"reference_price"`
+	PriceDate          *time.Time         `json:"price_date"`
+	Upside             *float64           `json:"upside"`
+	BrokerageCount     int                `json:"brokerage_count"`
+	LastChange         time.Time          `json:"last_change"`
+}
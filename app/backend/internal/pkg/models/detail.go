@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// TimelineFilter narrows a ticker's analyst event timeline; empty fields match every event
+type TimelineFilter struct {
+	Brokerage string    // matched case-insensitively
+	From      time.Time // events at or after From
+	To        time.Time // events before To
+}
+
+// TimelineEvent is one analyst action on a ticker with the rating and target change it made
+type TimelineEvent struct {
+	ID           uint      `json:"id"`
+	Time         time.Time `json:"time"`
+	Brokerage    string    `json:"brokerage"`
+	Action       string    `json:"action"`
+	RatingFrom   string    `json:"rating_from"`
+	RatingTo     string    `json:"rating_to"`
+	RatingChange *float64  `json:"rating_change"` // steps on the 1-5 rating scale, unset when either rating is unknown
+	TargetFrom   *float64  `json:"target_from"`
+	TargetTo     *float64  `json:"target_to"`
+	TargetChange *float64  `json:"target_change"` // percent, unset without both targets
+}
+
+// StockDetail gathers everything known about a ticker: its company, the street consensus, the
+// current recommendation with its history and the analyst event timeline, oldest first
+type StockDetail struct {
+	Ticker                string                   `json:"ticker"`
+	Company               string                   `json:"company"`
+	Sector                string                   `json:"sector,omitempty"`
+	Industry              string                   `json:"industry,omitempty"`
+	Consensus             Consensus                `json:"consensus"`
+	Recommendation        *StockRecommendation     `json:"recommendation"`
+	RecommendationHistory []RecommendationSnapshot `json:"recommendation_history"`
+	Timeline              []TimelineEvent          `json:"timeline"`
+}
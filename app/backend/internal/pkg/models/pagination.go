@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or does not match the
+// requested sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortKey orders a listing by one field
+type SortKey struct {
+	Field string `json:"f"`
+	Desc  bool   `json:"d,omitempty"`
+}
+
+// Cursor marks the last row of a page for keyset pagination: the sort the page was read in, the
+// row's value for each sort key and its id, which breaks ties
+type Cursor struct {
+	Sort   []SortKey     `json:"s"`
+	Values []interface{} `json:"v"`
+	ID     uint          `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe token
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Matches reports whether the cursor was taken under the given sort
+func (c Cursor) Matches(sort []SortKey) bool {
+	if len(c.Sort) != len(sort) {
+		return false
+	}
+	for i := range sort {
+		if c.Sort[i] != sort[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// StockPage is one page of a stocks listing
+type StockPage struct {
+	Stocks []Stock
+	Total  *int64  // events matching the filter; nil when counting was skipped
+	Next   *Cursor // nil on the last page
+}
+
+// RecommendationPage is one page of a recommendation listing
+type RecommendationPage struct {
+	Recommendations []StockRecommendation
+	Total           *int64  // recommendations matching the filter; nil when counting was skipped
+	Next            *Cursor // nil on the last page
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 || len(cursor.Values) != len(cursor.Sort) {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
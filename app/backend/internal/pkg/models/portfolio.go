@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// PortfolioConfig describes how to build a model portfolio from current recommendations
+type PortfolioConfig struct {
+	Name            string  `json:"name"`
+	Budget          float64 `json:"budget"`
+	RiskTolerance   string  `json:"risk_tolerance"`
+	TimeHorizon     string  `json:"time_horizon"`
+	MaxPositions    int     `json:"max_positions"`
+	MaxSectorWeight float64 `json:"max_sector_weight"` // percent of the budget
+	MinScore        float64 `json:"min_score"`
+}
+
+// Risk tolerances of a portfolio
+const (
+	RiskToleranceLow    = "low"
+	RiskToleranceMedium = "medium"
+	RiskToleranceHigh   = "high"
+)
+
+// Portfolio is a saved model portfolio and the settings it was built with
+type Portfolio struct {
+	ID              uint                `json:"id" gorm:"primaryKey"`
+	Name            string              `json:"name" gorm:"not null;size:255"`
+	Budget          float64             `json:"budget" gorm:"not null;type:decimal(14,2)"`
+	RiskTolerance   string              `json:"risk_tolerance" gorm:"not null;size:20"`
+	TimeHorizon     string              `json:"time_horizon" gorm:"size:20"`
+	MaxPositions    int                 `json:"max_positions"`
+	MaxSectorWeight float64             `json:"max_sector_weight" gorm:"type:decimal(5,2)"`
+	MinScore        float64             `json:"min_score" gorm:"type:decimal(5,2)"`
+	Invested        float64             `json:"invested" gorm:"type:decimal(14,2)"`
+	Cash            float64             `json:"cash" gorm:"type:decimal(14,2)"`
+	Positions       []PortfolioPosition `json:"positions,omitempty" gorm:"foreignKey:PortfolioID;constraint:OnDelete:CASCADE"`
+	CreatedAt       time.Time           `json:"created_at" gorm:"index"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// PortfolioPosition is one holding of a portfolio
+type PortfolioPosition struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	PortfolioID    uint      `json:"portfolio_id" gorm:"not null;index"`
+	Ticker         string    `json:"ticker" gorm:"not null;size:10"`
+	Company        string    `json:"company" gorm:"size:255"`
+	Sector         string    `json:"sector" gorm:"size:100"`
+	Weight         float64   `json:"weight" gorm:"not null;type:decimal(5,2)"` // percent of the budget
+	Amount         float64   `json:"amount" gorm:"not null;type:decimal(14,2)"`
+	Shares         *float64  `json:"shares" gorm:"type:decimal(14,4)"`
+	ReferencePrice *float64  `json:"reference_price" gorm:"type:decimal(12,4)"`
+	Score          float64   `json:"score" gorm:"type:decimal(5,2)"`
+	RiskLevel      string    `json:"risk_level" gorm:"size:20"`
+	Rationale      string    `json:"rationale" gorm:"type:text"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName sets the table name for Portfolio
+func (Portfolio) TableName() string {
+	return "portfolios"
+}
+
+// TableName sets the table name for PortfolioPosition
+func (PortfolioPosition) TableName() string {
+	return "portfolio_positions"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// StockPrice is one day of OHLCV price data for a ticker
+type StockPrice struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Ticker    string    `json:"ticker" gorm:"not null;size:10;uniqueIndex:idx_stock_prices_ticker_date,priority:1"`
+	Date      time.Time `json:"date" gorm:"not null;type:date;uniqueIndex:idx_stock_prices_ticker_date,priority:2"`
+	Open      float64   `json:"open" gorm:"type:decimal(12,4)"`
+	High      float64   `json:"high" gorm:"type:decimal(12,4)"`
+	Low       float64   `json:"low" gorm:"type:decimal(12,4)"`
+	Close     float64   `json:"close" gorm:"not null;type:decimal(12,4)"`
+	Volume    int64     `json:"volume"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for StockPrice
+func (StockPrice) TableName() string {
+	return "stock_prices"
+}
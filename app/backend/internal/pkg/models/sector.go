@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// TickerSector classifies a ticker into a sector and industry
+type TickerSector struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Ticker    string    `json:"ticker" gorm:"not null;size:10;uniqueIndex"`
+	Sector    string    `json:"sector" gorm:"not null;size:100;index"`
+	Industry  string    `json:"industry" gorm:"size:150"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SectorSummary aggregates the recommendations and recent analyst activity of one sector
+type SectorSummary struct {
+	Sector         string                `json:"sector"`
+	TickerCount    int                   `json:"ticker_count"`
+	AvgScore       float64               `json:"avg_score"`
+	Sentiment      string                `json:"sentiment"`
+	BullishCount   int                   `json:"bullish_count"`
+	NeutralCount   int                   `json:"neutral_count"`
+	BearishCount   int                   `json:"bearish_count"`
+	UpgradeCount   int                   `json:"upgrade_count"`
+	DowngradeCount int                   `json:"downgrade_count"`
+	NetUpgrades    int                   `json:"net_upgrades"`
+	TopPicks       []StockRecommendation `json:"top_picks" gorm:"-"`
+}
+
+// TableName sets the table name for TickerSector
+func (TickerSector) TableName() string {
+	return "ticker_sectors"
+}
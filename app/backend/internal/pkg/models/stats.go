@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// ActivityWindow counts the analyst events published over the last Days days
+type ActivityWindow struct {
+	Days       int `json:"days"`
+	Events     int `json:"events"`
+	Upgrades   int `json:"upgrades"`
+	Downgrades int `json:"downgrades"`
+}
+
+// BrokerageCount is a brokerage and the number of events it published in a window
+type BrokerageCount struct {
+	ID         string `json:"id" gorm:"-"` // directory id, see BrokerageSummary
+	Name       string `json:"name"`
+	EventCount int    `json:"event_count"`
+}
+
+// TickerCoverage is a ticker and how many brokerages have covered it
+type TickerCoverage struct {
+	Ticker         string `json:"ticker"`
+	Company        string `json:"company"`
+	BrokerageCount int    `json:"brokerage_count"`
+	EventCount     int    `json:"event_count"`
+}
+
+// DataFreshness tells how recent the stored data is
+type DataFreshness struct {
+	LatestEvent        *time.Time `json:"latest_event"`        // time of the newest analyst event
+	LastIngested       *time.Time `json:"last_ingested"`       // when an event was last stored or updated
+	LastRecommendation *time.Time `json:"last_recommendation"` // when the last completed recommendation run finished
+	LatestPrice        *time.Time `json:"latest_price"`        // date of the newest stored close
+}
+
+// DataVersion identifies the stored data an overview is built from; it changes with every
+// ingestion and every completed recommendation run
+type DataVersion struct {
+	LastIngested       *time.Time // when an event was last stored or updated
+	LastRecommendation *time.Time // when the last completed recommendation run finished
+}
+
+// Equal reports whether two data versions identify the same data
+func (v DataVersion) Equal(other DataVersion) bool {
+	return equalTimes(v.LastIngested, other.LastIngested) && equalTimes(v.LastRecommendation, other.LastRecommendation)
+}
+
+// equalTimes reports whether two optional times are both unset or the same instant
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// MarketOverview aggregates analyst activity across every ticker for the dashboard
+type MarketOverview struct {
+	TotalTickers         int                `json:"total_tickers"`
+	TotalEvents          int                `json:"total_events"`
+	TotalBrokerages      int                `json:"total_brokerages"`
+	Activity             []ActivityWindow   `json:"activity"`
+	MostActiveBrokerages []BrokerageCount   `json:"most_active_brokerages"` // over the longest activity window
+	MostCoveredTickers   []TickerCoverage   `json:"most_covered_tickers"`
+	RatingDistribution   RatingDistribution `json:"rating_distribution"` // current rating of every ticker and brokerage pair
+	Freshness            DataFreshness      `json:"freshness"`
+	GeneratedAt          time.Time          `json:"generated_at"`
+}
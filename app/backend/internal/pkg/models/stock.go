@@ -1,6 +1,9 @@
 package models
 
 import (
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,38 +11,167 @@ import (
 
 // Stock represents a stock entity in the database
 type Stock struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Ticker      string         `json:"ticker" gorm:"uniqueIndex;not null;size:10"`
-	Company     string         `json:"company" gorm:"not null;size:255"`
-	TargetFrom  string         `json:"target_from" gorm:"size:20"`
-	TargetTo    string         `json:"target_to" gorm:"size:20"`
-	Action      string         `json:"action" gorm:"size:50"`
-	Brokerage   string         `json:"brokerage" gorm:"size:255"`
-	RatingFrom  string         `json:"rating_from" gorm:"size:50"`
-	RatingTo    string         `json:"rating_to" gorm:"size:50"`
-	Time        time.Time      `json:"time"`
-	LastUpdated time.Time      `json:"last_updated" gorm:"autoUpdateTime"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Ticker          string         `json:"ticker" gorm:"not null;size:10;index;uniqueIndex:idx_stocks_event,priority:1"`
+	Company         string         `json:"company" gorm:"not null;size:255"`
+	TargetFrom      string         `json:"target_from" gorm:"size:20"`
+	TargetTo        string         `json:"target_to" gorm:"size:20"`
+	TargetFromValue *float64       `json:"target_from_value" gorm:"type:decimal(12,2)"`
+	TargetToValue   *float64       `json:"target_to_value" gorm:"type:decimal(12,2)"` // numeric copies kept in step by BeforeSave
+	Action          string         `json:"action" gorm:"size:50"`
+	Brokerage       string         `json:"brokerage" gorm:"size:255;uniqueIndex:idx_stocks_event,priority:2"`
+	RatingFrom      string         `json:"rating_from" gorm:"size:50"`
+	RatingTo        string         `json:"rating_to" gorm:"size:50"`
+	Time            time.Time      `json:"time" gorm:"uniqueIndex:idx_stocks_event,priority:3"` // one event per ticker, brokerage and time
+	LastUpdated     time.Time      `json:"last_updated" gorm:"autoUpdateTime"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+	Upside          *float64       `json:"upside,omitempty" gorm:"->;-:migration"` // % of target_to over the latest close, upside sort only
 }
 
 // StockRecommendation represents a stock recommendation
 type StockRecommendation struct {
-	ID                uint           `json:"id" gorm:"primaryKey"`
-	StockID           uint           `json:"stock_id" gorm:"not null;index"`
-	Stock             Stock          `json:"stock" gorm:"foreignKey:StockID"`
-	RecommendationScore float64      `json:"recommendation_score" gorm:"not null;type:decimal(5,2);index"`
-	RiskLevel         string         `json:"risk_level" gorm:"not null;size:20"`
-	ExpectedReturn    float64        `json:"expected_return" gorm:"type:decimal(5,2)"`
-	TimeHorizon       string         `json:"time_horizon" gorm:"size:20"`
-	Reason            string         `json:"reason" gorm:"type:text"`
-	AnalystSentiment  string         `json:"analyst_sentiment" gorm:"size:20"`
-	UpgradeCount      int            `json:"upgrade_count" gorm:"default:0"`
-	DowngradeCount    int            `json:"downgrade_count" gorm:"default:0"`
-	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at"`
-	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	StockID             uint           `json:"stock_id" gorm:"not null;index"`
+	Ticker              string         `json:"ticker" gorm:"size:10;index"`
+	Stock               Stock          `json:"stock" gorm:"foreignKey:StockID"`
+	RecommendationScore float64        `json:"recommendation_score" gorm:"not null;type:decimal(5,2);index"`
+	ScoreLower          float64        `json:"score_lower" gorm:"type:decimal(5,2)"`
+	ScoreUpper          float64        `json:"score_upper" gorm:"type:decimal(5,2)"`
+	Confidence          float64        `json:"confidence" gorm:"type:decimal(5,2);index"` // 100 minus the interval width
+	RiskLevel           string         `json:"risk_level" gorm:"not null;size:20"`
+	RiskScore           float64        `json:"risk_score" gorm:"type:decimal(5,2)"`
+	ExpectedReturn      float64        `json:"expected_return" gorm:"type:decimal(5,2)"`
+	ExpectedReturnLow   float64        `json:"expected_return_low" gorm:"type:decimal(5,2)"`
+	ExpectedReturnHigh  float64        `json:"expected_return_high" gorm:"type:decimal(5,2)"`
+	Upside              *float64       `json:"upside" gorm:"type:decimal(5,2)"`
+	ReferencePrice      *float64       `json:"reference_price" gorm:"type:decimal(12,4)"`
+	TargetCount         int            `json:"target_count" gorm:"default:0"`
+	TargetMean          float64        `json:"target_mean" gorm:"type:decimal(12,2)"`
+	TargetMedian        float64        `json:"target_median" gorm:"type:decimal(12,2)"`
+	TargetHigh          float64        `json:"target_high" gorm:"type:decimal(12,2)"`
+	TargetLow           float64        `json:"target_low" gorm:"type:decimal(12,2)"`
+	TargetChange        float64        `json:"target_change" gorm:"type:decimal(5,2)"`
+	TimeHorizon         string         `json:"time_horizon" gorm:"size:20"`
+	Reason              string         `json:"reason" gorm:"type:text"`
+	AnalystSentiment    string         `json:"analyst_sentiment" gorm:"size:20"`
+	ConsensusRating     string         `json:"consensus_rating" gorm:"size:20"`
+	BrokerageCount      int            `json:"brokerage_count" gorm:"default:0"`
+	UpgradeCount        int            `json:"upgrade_count" gorm:"default:0"`
+	DowngradeCount      int            `json:"downgrade_count" gorm:"default:0"`
+	Percentile          *float64       `json:"percentile" gorm:"type:decimal(5,2)"` // rank among the horizon's scores, 0-100
+	ZScore              *float64       `json:"z_score" gorm:"type:decimal(6,3)"`
+	Grade               *string        `json:"grade" gorm:"size:1"` // A-E by percentile, unset when grading is off
+	ScoreDelta          *float64       `json:"score_delta,omitempty" gorm:"->;-:migration"`
+	Sector              string         `json:"sector,omitempty" gorm:"->;-:migration"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// RecommendationRun records one execution of the recommendation engine
+type RecommendationRun struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Full        bool       `json:"full" gorm:"not null;default:false"`
+	Since       *time.Time `json:"since"`
+	Status      string     `json:"status" gorm:"not null;size:20;index"`
+	TickerCount int        `json:"ticker_count" gorm:"default:0"`
+	ErrorCount  int        `json:"error_count" gorm:"default:0"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt   time.Time  `json:"started_at" gorm:"not null;index"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// RecommendationSnapshot stores a ticker's rank and score as of one recommendation run
+type RecommendationSnapshot struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RunID       uint      `json:"run_id" gorm:"not null;index"`
+	Ticker      string    `json:"ticker" gorm:"not null;size:10"`
+	TimeHorizon string    `json:"time_horizon" gorm:"size:20"`
+	Rank        int       `json:"rank" gorm:"not null"`
+	Score       float64   `json:"score" gorm:"not null;type:decimal(5,2)"`
+	Percentile  *float64  `json:"percentile" gorm:"type:decimal(5,2)"`
+	ZScore      *float64  `json:"z_score" gorm:"type:decimal(6,3)"`
+	Grade       *string   `json:"grade" gorm:"size:1"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Recommendation run statuses
+const (
+	RunStatusRunning   = "running"
+	RunStatusCompleted = "completed"
+	RunStatusPartial   = "partial"
+	RunStatusFailed    = "failed"
+	RunStatusCancelled = "cancelled"
+)
+
+// Recommendation time horizons
+const (
+	TimeHorizonShort  = "short"
+	TimeHorizonMedium = "medium"
+	TimeHorizonLong   = "long"
+)
+
+// TimeHorizons lists every horizon a ticker is scored for
+var TimeHorizons = []string{TimeHorizonShort, TimeHorizonMedium, TimeHorizonLong}
+
+// Grades lists recommendation grades from best to worst
+var Grades = []string{"A", "B", "C", "D", "E"}
+
+// StockFilter narrows an analyst event listing; empty fields match every event
+type StockFilter struct {
+	Query      string // substring of the ticker or company
+	Brokerage  string
+	Action     string
+	RatingFrom string
+	RatingTo   string
+	From       time.Time // events at or after From
+	To         time.Time // events before To
+	MinTarget  *float64  // bounds on the numeric target_to
+	MaxTarget  *float64
+	Sort       []SortKey // DefaultStockSort when empty
+	After      *Cursor   // keyset pagination; replaces Offset when set
+	Limit      int
+	Offset     int
+}
+
+// StockSortFields are the fields a stocks listing can be sorted by
+var StockSortFields = []string{"time", "ticker", "company", "brokerage", "rating_to", "target", "upside"}
+
+// DefaultStockSort lists events newest first
+var DefaultStockSort = []SortKey{{Field: "time", Desc: true}}
+
+// RecommendationSortFields are the fields a recommendation listing can be sorted by: score,
+// expected return and risk score
+var RecommendationSortFields = []string{"score", "return", "risk"}
+
+// RecommendationScoreSort lists recommendations best score first
+var RecommendationScoreSort = []SortKey{{Field: "score", Desc: true}}
+
+// RiskLevels lists the risk levels a recommendation can have
+var RiskLevels = []string{"low", "medium", "high"}
+
+// AnalystSentiments lists the analyst sentiments a recommendation can have
+var AnalystSentiments = []string{"bullish", "neutral", "bearish"}
+
+// RecommendationFilter narrows a recommendation listing
+type RecommendationFilter struct {
+	TimeHorizon   string
+	Sector        string // matched case-insensitively against ticker_sectors
+	RiskLevel     string
+	Sentiment     string
+	Query         string // substring of the ticker
+	MinScore      float64
+	MaxScore      *float64
+	MinReturn     *float64 // bound on the expected return, in percent
+	MinConfidence float64
+	Sort          []SortKey // RecommendationScoreSort when empty; ignored by the movers listing
+	After         *Cursor   // keyset pagination; replaces Offset when set, ignored by the movers listing
+	Limit         int
+	Offset        int
 }
 
 // TableName sets the table name for Stock
@@ -47,7 +179,88 @@ func (Stock) TableName() string {
 	return "stocks"
 }
 
+// SortValue returns the event's value for a field of StockSortFields, as a cursor stores it
+func (s Stock) SortValue(field string) interface{} {
+	switch field {
+	case "time":
+		return s.Time
+	case "ticker":
+		return s.Ticker
+	case "company":
+		return s.Company
+	case "brokerage":
+		return s.Brokerage
+	case "rating_to":
+		return s.RatingTo
+	case "target":
+		return s.TargetToValue
+	case "upside":
+		return s.Upside
+	}
+	return nil
+}
+
+// maxTargetValue bounds numeric targets to what decimal(12,2) can store
+const maxTargetValue = 1e9
+
+// BeforeSave keeps the numeric target columns in step with the target strings
+func (s *Stock) BeforeSave(tx *gorm.DB) error {
+	s.TargetFromValue = targetValue(s.TargetFrom)
+	s.TargetToValue = targetValue(s.TargetTo)
+	return nil
+}
+
+// targetValue returns a target string's storable numeric value, or nil when it has none
+func targetValue(raw string) *float64 {
+	value, ok := ParseTargetPrice(raw)
+	if !ok || value >= maxTargetValue {
+		return nil
+	}
+	value = math.Round(value*100) / 100
+	return &value
+}
+
+// ParseTargetPrice converts a target string such as "$1,250.00" into a number
+func ParseTargetPrice(raw string) (float64, bool) {
+	cleaned := strings.TrimSpace(raw)
+	cleaned = strings.TrimPrefix(cleaned, "$")
+	cleaned = strings.ReplaceAll(cleaned, ",", "")
+	if cleaned == "" {
+		return 0, false
+	}
+
+	value, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || value <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, false
+	}
+	return value, true
+}
+
 // TableName sets the table name for StockRecommendation
 func (StockRecommendation) TableName() string {
 	return "stock_recommendations"
-}
\ No newline at end of file
+}
+
+// SortValue returns the recommendation's value for a field of RecommendationSortFields, as a
+// cursor stores it
+func (r StockRecommendation) SortValue(field string) interface{} {
+	switch field {
+	case "score":
+		return r.RecommendationScore
+	case "return":
+		return r.ExpectedReturn
+	case "risk":
+		return r.RiskScore
+	}
+	return nil
+}
+
+// TableName sets the table name for RecommendationRun
+func (RecommendationRun) TableName() string {
+	return "recommendation_runs"
+}
+
+// TableName sets the table name for RecommendationSnapshot
+func (RecommendationSnapshot) TableName() string {
+	return "recommendation_snapshots"
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"truora-backend/internal/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AnomalyRepository interface {
+	UpsertAnomalies(ctx context.Context, anomalies []models.Anomaly) error
+	GetAnomalies(filter models.AnomalyFilter) ([]models.Anomaly, error)
+}
+
+type anomalyRepository struct {
+	db *gorm.DB
+}
+
+// NewAnomalyRepository creates a new anomaly repository
+func NewAnomalyRepository(db *gorm.DB) AnomalyRepository {
+	return &anomalyRepository{db: db}
+}
+
+// UpsertAnomalies stores detected anomalies. An anomaly found again for the same ticker, type and
+// window start replaces the earlier one, so re-running the detector does not duplicate it.
+// Anomalies sharing that key within one call are collapsed first, as one upsert cannot touch a
+// row twice.
+func (r *anomalyRepository) UpsertAnomalies(ctx context.Context, anomalies []models.Anomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "ticker"}, {Name: "type"}, {Name: "window_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"severity", "description", "brokerages", "event_count", "magnitude", "window_end", "detected_at", "updated_at",
+		}),
+	}).CreateInBatches(dedupeAnomalies(anomalies), 100).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert anomalies: %w", err)
+	}
+	return nil
+}
+
+// anomalySeverityRank orders severities from least to most severe
+var anomalySeverityRank = map[string]int{
+	models.SeverityLow:    0,
+	models.SeverityMedium: 1,
+	models.SeverityHigh:   2,
+}
+
+// dedupeAnomalies keeps one anomaly per ticker, type and window start: the most severe, or the
+// later one of equal severity
+func dedupeAnomalies(anomalies []models.Anomaly) []models.Anomaly {
+	seen := make(map[string]int)
+	deduped := make([]models.Anomaly, 0, len(anomalies))
+	for _, anomaly := range anomalies {
+		key := anomaly.Ticker + "|" + anomaly.Type + "|" + anomaly.WindowStart.UTC().Format(time.RFC3339Nano)
+		if i, ok := seen[key]; ok {
+			if anomalySeverityRank[anomaly.Severity] >= anomalySeverityRank[deduped[i].Severity] {
+				deduped[i] = anomaly
+			}
+			continue
+		}
+		seen[key] = len(deduped)
+		deduped = append(deduped, anomaly)
+	}
+	return deduped
+}
+
+// GetAnomalies retrieves anomalies matching a filter, most recent first and most severe first
+// within the same window end
+func (r *anomalyRepository) GetAnomalies(filter models.AnomalyFilter) ([]models.Anomaly, error) {
+	query := r.db.Model(&models.Anomaly{})
+	if filter.Ticker != "" {
+		query = query.Where("ticker = ?", filter.Ticker)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Severity != "" {
+		query = query.Where("severity = ?", filter.Severity)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("window_end >= ?", filter.Since)
+	}
+
+	var anomalies []models.Anomaly
+	if err := query.Order("window_end DESC").
+		Order("CASE severity WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END").
+		Order("id").
+		Limit(filter.Limit).Find(&anomalies).Error; err != nil {
+		return nil, fmt.Errorf("failed to get anomalies: %w", err)
+	}
+	return anomalies, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"truora-backend/internal/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+type BrokerageRepository interface {
+	ReplaceScores(ctx context.Context, scores []models.BrokerageScore) error
+	GetLeaderboard(minCalls, limit int) ([]models.BrokerageScore, error)
+	GetAllScores() ([]models.BrokerageScore, error)
+	GetScore(brokerage string) (*models.BrokerageScore, error)
+	GetDirectory() ([]models.BrokerageSummary, error)
+	FindBrokerages(name, slug string) ([]models.BrokerageSummary, error)
+	GetCoverage(brokerage string) ([]models.BrokerageCoverage, error)
+	GetActivity(brokerage string) (*models.BrokerageActivity, error)
+}
+
+type brokerageRepository struct {
+	db *gorm.DB
+}
+
+// NewBrokerageRepository creates a new brokerage repository
+func NewBrokerageRepository(db *gorm.DB) BrokerageRepository {
+	return &brokerageRepository{db: db}
+}
+
+// ReplaceScores swaps the stored track records for a freshly evaluated set in one transaction
+func (r *brokerageRepository) ReplaceScores(ctx context.Context, scores []models.BrokerageScore) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.BrokerageScore{}).Error; err != nil {
+			return err
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(scores, 100).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace brokerage scores: %w", err)
+	}
+	return nil
+}
+
+// GetLeaderboard retrieves brokerages with at least minCalls evaluated calls, most credible first
+func (r *brokerageRepository) GetLeaderboard(minCalls, limit int) ([]models.BrokerageScore, error) {
+	var scores []models.BrokerageScore
+	if err := r.db.Where("calls >= ?", minCalls).
+		Order("credibility DESC, calls DESC, brokerage").
+		Limit(limit).Find(&scores).Error; err != nil {
+		return nil, fmt.Errorf("failed to get brokerage leaderboard: %w", err)
+	}
+	return scores, nil
+}
+
+// GetAllScores retrieves every stored brokerage track record
+func (r *brokerageRepository) GetAllScores() ([]models.BrokerageScore, error) {
+	var scores []models.BrokerageScore
+	if err := r.db.Find(&scores).Error; err != nil {
+		return nil, fmt.Errorf("failed to get brokerage scores: %w", err)
+	}
+	return scores, nil
+}
+
+// GetScore retrieves a brokerage's track record, or nil when it has not been evaluated. Names
+// match trimmed and case-insensitively, as track records are keyed.
+func (r *brokerageRepository) GetScore(brokerage string) (*models.BrokerageScore, error) {
+	var score models.BrokerageScore
+	if err := r.db.Where("LOWER(TRIM(brokerage)) = LOWER(TRIM(?))", brokerage).First(&score).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get brokerage score: %w", err)
+	}
+	return &score, nil
+}
+
+// brokerageSlugSQL derives a brokerage's slug in SQL the way the service's brokerageSlug does:
+// lowercase letters and digits with every other run of characters turned into a single dash
+const brokerageSlugSQL = `TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(stocks.brokerage), '[^a-z0-9]+', '-', 'g'))`
+
+// GetDirectory aggregates the analyst events of every brokerage, most active first
+func (r *brokerageRepository) GetDirectory() ([]models.BrokerageSummary, error) {
+	var summaries []models.BrokerageSummary
+	if err := r.summaries().Order("event_count DESC, name").Scan(&summaries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get brokerage directory: %w", err)
+	}
+	return summaries, nil
+}
+
+// FindBrokerages aggregates the analyst events of the brokerages with a name or a slug. Several
+// brokerages are returned when their names differ only in case or punctuation.
+func (r *brokerageRepository) FindBrokerages(name, slug string) ([]models.BrokerageSummary, error) {
+	var summaries []models.BrokerageSummary
+	if err := r.summaries().
+		Where("stocks.brokerage = ? OR "+brokerageSlugSQL+" = ?", name, slug).
+		Order("name").
+		Scan(&summaries).Error; err != nil {
+		return nil, fmt.Errorf("failed to find brokerage: %w", err)
+	}
+	return summaries, nil
+}
+
+// summaries builds the directory aggregate of every brokerage with analyst events. Track records
+// join on the trimmed, lowercased name they are keyed by.
+func (r *brokerageRepository) summaries() *gorm.DB {
+	return r.db.Model(&models.Stock{}).
+		Select(`stocks.brokerage AS name, COUNT(*) AS event_count, COUNT(DISTINCT stocks.ticker) AS ticker_count,
+			MIN(stocks.time) AS first_activity, MAX(stocks.time) AS last_activity, brokerage_scores.credibility`).
+		Joins("LEFT JOIN brokerage_scores ON LOWER(TRIM(brokerage_scores.brokerage)) = LOWER(TRIM(stocks.brokerage))").
+		Where("stocks.brokerage <> ''").
+		Group("stocks.brokerage, brokerage_scores.credibility")
+}
+
+// GetCoverage retrieves every ticker a brokerage has acted on with its latest event on it, most
+// recently active first
+func (r *brokerageRepository) GetCoverage(brokerage string) ([]models.BrokerageCoverage, error) {
+	latest := r.db.Model(&models.Stock{}).
+		Select("DISTINCT ON (ticker) ticker, company, rating_to, target_to, action, time AS last_activity").
+		Where("brokerage = ?", brokerage).
+		Order("ticker, time DESC")
+
+	var coverage []models.BrokerageCoverage
+	if err := r.db.Table("(?) AS latest", latest).
+		Order("last_activity DESC, ticker").
+		Scan(&coverage).Error; err != nil {
+		return nil, fmt.Errorf("failed to get brokerage coverage: %w", err)
+	}
+	return coverage, nil
+}
+
+// GetActivity counts a brokerage's upgrades and downgrades and averages the percent change of
+// its price targets
+func (r *brokerageRepository) GetActivity(brokerage string) (*models.BrokerageActivity, error) {
+	var activity models.BrokerageActivity
+	if err := r.db.Model(&models.Stock{}).
+		Select(`COALESCE(SUM(CASE WHEN action ILIKE '%upgrade%' THEN 1 ELSE 0 END), 0) AS upgrade_count,
+			COALESCE(SUM(CASE WHEN action ILIKE '%downgrade%' THEN 1 ELSE 0 END), 0) AS downgrade_count,
+			ROUND(AVG(CASE WHEN target_from_value > 0 THEN (target_to_value - target_from_value) / target_from_value * 100 END), 2) AS avg_target_change`).
+		Where("brokerage = ?", brokerage).
+		Scan(&activity).Error; err != nil {
+		return nil, fmt.Errorf("failed to get brokerage activity: %w", err)
+	}
+	return &activity, nil
+}
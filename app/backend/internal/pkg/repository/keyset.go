@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+)
+
+// keysetColumn is one sort expression of a keyset-paginated query and the cursor's value for it
+type keysetColumn struct {
+	expr     string
+	desc     bool
+	nullable bool // NULLs sort after every value in either direction
+	value    interface{}
+}
+
+// keysetCondition builds the condition selecting the rows that sort after a cursor position, as
+// (c1 after v1) OR (c1 = v1 AND c2 after v2) OR ... ending with the id tiebreaker. A nullable column
+// orders as "IS NULL" followed by its value, matching how the listings order them.
+func keysetCondition(columns []keysetColumn, idColumn string, idDesc bool, id uint) (string, []interface{}) {
+	type step struct {
+		after, equal string
+		afterArgs    []interface{}
+		equalArgs    []interface{}
+	}
+
+	comparison := func(desc bool) string {
+		if desc {
+			return "<"
+		}
+		return ">"
+	}
+
+	var steps []step
+	for _, column := range columns {
+		if column.nullable {
+			if column.value == nil {
+				// Past the last value nothing but more NULLs can follow
+				steps = append(steps, step{equal: column.expr + " IS NULL"})
+				continue
+			}
+			steps = append(steps, step{after: column.expr + " IS NULL", equal: column.expr + " IS NOT NULL"})
+		}
+		steps = append(steps, step{
+			after:     column.expr + " " + comparison(column.desc) + " ?",
+			afterArgs: []interface{}{column.value},
+			equal:     column.expr + " = ?",
+			equalArgs: []interface{}{column.value},
+		})
+	}
+	steps = append(steps, step{after: idColumn + " " + comparison(idDesc) + " ?", afterArgs: []interface{}{id}})
+
+	var branches []string
+	var args []interface{}
+	var prefix []string
+	var prefixArgs []interface{}
+	for _, s := range steps {
+		if s.after != "" {
+			branches = append(branches, "("+strings.Join(append(append([]string{}, prefix...), s.after), " AND ")+")")
+			args = append(args, prefixArgs...)
+			args = append(args, s.afterArgs...)
+		}
+		if s.equal != "" {
+			prefix = append(prefix, s.equal)
+			prefixArgs = append(prefixArgs, s.equalArgs...)
+		}
+	}
+	return "(" + strings.Join(branches, " OR ") + ")", args
+}
+
+// cursorTime converts a cursor value stored for a timestamp column back into a time
+func cursorTime(value interface{}) (time.Time, error) {
+	text, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: expected a timestamp", models.ErrInvalidCursor)
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: expected a timestamp", models.ErrInvalidCursor)
+	}
+	return parsed, nil
+}
+
+// cursorNumber checks that a cursor value stored for a numeric column is a number, or nil when
+// the column is nullable
+func cursorNumber(value interface{}, nullable bool) (interface{}, error) {
+	if value == nil && nullable {
+		return nil, nil
+	}
+	if _, ok := value.(float64); !ok {
+		return nil, fmt.Errorf("%w: expected a number", models.ErrInvalidCursor)
+	}
+	return value, nil
+}
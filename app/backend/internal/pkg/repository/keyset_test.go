@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"truora-backend/internal/pkg/models"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	at := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		columns   []keysetColumn
+		idDesc    bool
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "id only",
+			wantQuery: "((stocks.id > ?))",
+			wantArgs:  []interface{}{uint(42)},
+		},
+		{
+			name:      "descending column breaks ties on a descending id",
+			columns:   []keysetColumn{{expr: "stocks.time", desc: true, value: at}},
+			idDesc:    true,
+			wantQuery: "((stocks.time < ?) OR (stocks.time = ? AND stocks.id < ?))",
+			wantArgs:  []interface{}{at, at, uint(42)},
+		},
+		{
+			name: "mixed directions carry every equal column into the later branches",
+			columns: []keysetColumn{
+				{expr: "stocks.ticker", value: "AAPL"},
+				{expr: "stocks.time", desc: true, value: at},
+			},
+			idDesc: true,
+			wantQuery: "((stocks.ticker > ?) OR (stocks.ticker = ? AND stocks.time < ?) OR " +
+				"(stocks.ticker = ? AND stocks.time = ? AND stocks.id < ?))",
+			wantArgs: []interface{}{"AAPL", "AAPL", at, "AAPL", at, uint(42)},
+		},
+		{
+			name:    "nullable column with a value is followed by its NULLs",
+			columns: []keysetColumn{{expr: "target", nullable: true, value: 150.0}},
+			wantQuery: "((target IS NULL) OR (target IS NOT NULL AND target > ?) OR " +
+				"(target IS NOT NULL AND target = ? AND stocks.id > ?))",
+			wantArgs: []interface{}{150.0, 150.0, uint(42)},
+		},
+		{
+			name:      "nullable column past its last value only reads NULLs",
+			columns:   []keysetColumn{{expr: "target", desc: true, nullable: true}},
+			idDesc:    true,
+			wantQuery: "((target IS NULL AND stocks.id < ?))",
+			wantArgs:  []interface{}{uint(42)},
+		},
+		{
+			name: "NULL cursor value keeps the earlier columns' branches",
+			columns: []keysetColumn{
+				{expr: "stocks.ticker", value: "AAPL"},
+				{expr: "upside", desc: true, nullable: true},
+			},
+			idDesc: true,
+			wantQuery: "((stocks.ticker > ?) OR " +
+				"(stocks.ticker = ? AND upside IS NULL AND stocks.id < ?))",
+			wantArgs: []interface{}{"AAPL", "AAPL", uint(42)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := keysetCondition(tt.columns, "stocks.id", tt.idDesc, 42)
+			if query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestCursorTime(t *testing.T) {
+	want := time.Date(2025, 3, 14, 9, 30, 0, 123, time.UTC)
+	got, err := cursorTime(want.Format(time.RFC3339Nano))
+	if err != nil || !got.Equal(want) {
+		t.Fatalf("cursorTime = %v, %v, want %v", got, err, want)
+	}
+
+	for _, value := range []interface{}{nil, 12.5, "yesterday", "2025-03-14"} {
+		if _, err := cursorTime(value); !errors.Is(err, models.ErrInvalidCursor) {
+			t.Errorf("cursorTime(%v) error = %v, want ErrInvalidCursor", value, err)
+		}
+	}
+}
+
+func TestCursorNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		nullable bool
+		want     interface{}
+		wantErr  bool
+	}{
+		{name: "number", value: 12.5, want: 12.5},
+		{name: "NULL of a nullable column", value: nil, nullable: true, want: nil},
+		{name: "NULL of a required column", value: nil, wantErr: true},
+		{name: "text", value: "12.5", nullable: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cursorNumber(tt.value, tt.nullable)
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrInvalidCursor) {
+					t.Fatalf("error = %v, want ErrInvalidCursor", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("cursorNumber = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyStockCursorRejectsInvalidCursors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter models.StockFilter
+	}{
+		{
+			name: "cursor taken under another sort",
+			filter: models.StockFilter{
+				Sort:  []models.SortKey{{Field: "ticker"}},
+				After: &models.Cursor{Sort: models.DefaultStockSort, Values: []interface{}{"2025-03-14T09:30:00Z"}, ID: 1},
+			},
+		},
+		{
+			name: "cursor direction differs from the default sort",
+			filter: models.StockFilter{
+				After: &models.Cursor{Sort: []models.SortKey{{Field: "time"}}, Values: []interface{}{"2025-03-14T09:30:00Z"}, ID: 1},
+			},
+		},
+		{
+			name: "time value is not a timestamp",
+			filter: models.StockFilter{
+				After: &models.Cursor{Sort: models.DefaultStockSort, Values: []interface{}{12.5}, ID: 1},
+			},
+		},
+		{
+			name: "target value is text",
+			filter: models.StockFilter{
+				Sort:  []models.SortKey{{Field: "target", Desc: true}},
+				After: &models.Cursor{Sort: []models.SortKey{{Field: "target", Desc: true}}, Values: []interface{}{"$150"}, ID: 1},
+			},
+		},
+		{
+			name: "ticker value is a number",
+			filter: models.StockFilter{
+				Sort:  []models.SortKey{{Field: "ticker"}},
+				After: &models.Cursor{Sort: []models.SortKey{{Field: "ticker"}}, Values: []interface{}{1.0}, ID: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := applyStockCursor(nil, tt.filter); !errors.Is(err, models.ErrInvalidCursor) {
+				t.Fatalf("error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
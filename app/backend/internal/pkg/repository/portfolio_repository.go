@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"fmt"
+	"truora-backend/internal/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+type PortfolioRepository interface {
+	CreatePortfolio(portfolio *models.Portfolio) error
+	GetPortfolios(limit, offset int) ([]models.Portfolio, error)
+	GetPortfolio(id uint) (*models.Portfolio, error)
+}
+
+type portfolioRepository struct {
+	db *gorm.DB
+}
+
+// NewPortfolioRepository creates a new portfolio repository
+func NewPortfolioRepository(db *gorm.DB) PortfolioRepository {
+	return &portfolioRepository{db: db}
+}
+
+// CreatePortfolio saves a portfolio together with its positions
+func (r *portfolioRepository) CreatePortfolio(portfolio *models.Portfolio) error {
+	if err := r.db.Create(portfolio).Error; err != nil {
+		return fmt.Errorf("failed to create portfolio: %w", err)
+	}
+	return nil
+}
+
+// GetPortfolios retrieves saved portfolios without their positions, newest first
+func (r *portfolioRepository) GetPortfolios(limit, offset int) ([]models.Portfolio, error) {
+	var portfolios []models.Portfolio
+	if err := r.db.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&portfolios).Error; err != nil {
+		return nil, fmt.Errorf("failed to get portfolios: %w", err)
+	}
+	return portfolios, nil
+}
+
+// GetPortfolio retrieves a portfolio with its positions, largest first, or nil if it does not exist
+func (r *portfolioRepository) GetPortfolio(id uint) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	err := r.db.Preload("Positions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("weight DESC, ticker")
+	}).First(&portfolio, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get portfolio: %w", err)
+	}
+	return &portfolio, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+	"truora-backend/internal/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PriceRepository interface {
+	UpsertPrices(prices []models.StockPrice) error
+	GetLatestPrice(ticker string) (*models.StockPrice, error)
+	GetPriceOn(ticker string, date time.Time) (*models.StockPrice, error)
+	GetPrices(ticker string, from, to time.Time, limit int) ([]models.StockPrice, error)
+}
+
+type priceRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceRepository creates a new price repository
+func NewPriceRepository(db *gorm.DB) PriceRepository {
+	return &priceRepository{db: db}
+}
+
+// UpsertPrices inserts daily prices, overwriting any existing row for the same ticker and date
+func (r *priceRepository) UpsertPrices(prices []models.StockPrice) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ticker"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume", "updated_at"}),
+	}).CreateInBatches(prices, 500).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert prices: %w", err)
+	}
+	return nil
+}
+
+// GetLatestPrice retrieves the most recent price of a ticker, or nil if it has none
+func (r *priceRepository) GetLatestPrice(ticker string) (*models.StockPrice, error) {
+	var price models.StockPrice
+	if err := r.db.Where("ticker = ?", ticker).Order("date DESC").First(&price).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest price: %w", err)
+	}
+	return &price, nil
+}
+
+// GetPriceOn retrieves the price of a ticker on a date, falling back to the last trading day
+// before it. Returns nil if the ticker has no price on or before the date.
+func (r *priceRepository) GetPriceOn(ticker string, date time.Time) (*models.StockPrice, error) {
+	var price models.StockPrice
+	if err := r.db.Where("ticker = ? AND date <= ?", ticker, date.Format("2006-01-02")).
+		Order("date DESC").First(&price).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get price on date: %w", err)
+	}
+	return &price, nil
+}
+
+// GetPrices retrieves a ticker's daily prices between from and to (inclusive), newest first.
+// A zero from or to leaves that end of the range open.
+func (r *priceRepository) GetPrices(ticker string, from, to time.Time, limit int) ([]models.StockPrice, error) {
+	query := r.db.Where("ticker = ?", ticker)
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from.Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		query = query.Where("date <= ?", to.Format("2006-01-02"))
+	}
+
+	var prices []models.StockPrice
+	if err := query.Order("date DESC").Limit(limit).Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get prices: %w", err)
+	}
+	return prices, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+	"truora-backend/internal/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SectorRepository interface {
+	UpsertSectors(sectors []models.TickerSector) error
+	GetSectorsByTicker(tickers []string) ([]models.TickerSector, error)
+	GetSectorStats(horizon, sector string) ([]models.SectorSummary, error)
+	GetSectorActivity(since time.Time, sector string) ([]models.SectorSummary, error)
+	GetSectorTopPicks(horizon, sector string, perSector int) ([]models.StockRecommendation, error)
+}
+
+type sectorRepository struct {
+	db *gorm.DB
+}
+
+// NewSectorRepository creates a new sector repository
+func NewSectorRepository(db *gorm.DB) SectorRepository {
+	return &sectorRepository{db: db}
+}
+
+// UpsertSectors inserts ticker classifications, overwriting the sector and industry of known tickers
+func (r *sectorRepository) UpsertSectors(sectors []models.TickerSector) error {
+	if len(sectors) == 0 {
+		return nil
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ticker"}},
+		DoUpdates: clause.AssignmentColumns([]string{"sector", "industry", "updated_at"}),
+	}).CreateInBatches(sectors, 500).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert sectors: %w", err)
+	}
+	return nil
+}
+
+// GetSectorsByTicker retrieves the classifications of the given tickers; unclassified tickers are left out
+func (r *sectorRepository) GetSectorsByTicker(tickers []string) ([]models.TickerSector, error) {
+	if len(tickers) == 0 {
+		return nil, nil
+	}
+
+	var sectors []models.TickerSector
+	if err := r.db.Where("ticker IN ?", tickers).Find(&sectors).Error; err != nil {
+		return nil, fmt.Errorf("failed to get ticker sectors: %w", err)
+	}
+	return sectors, nil
+}
+
+// GetSectorStats aggregates the recommendations of a time horizon by sector. An empty sector
+// returns every sector.
+func (r *sectorRepository) GetSectorStats(horizon, sector string) ([]models.SectorSummary, error) {
+	query := r.db.Table("stock_recommendations AS sr").
+		Select(`ts.sector,
+			COUNT(*) AS ticker_count,
+			AVG(sr.recommendation_score) AS avg_score,
+			SUM(CASE WHEN sr.analyst_sentiment = 'bullish' THEN 1 ELSE 0 END) AS bullish_count,
+			SUM(CASE WHEN sr.analyst_sentiment = 'neutral' THEN 1 ELSE 0 END) AS neutral_count,
+			SUM(CASE WHEN sr.analyst_sentiment = 'bearish' THEN 1 ELSE 0 END) AS bearish_count`).
+		Joins("JOIN ticker_sectors AS ts ON ts.ticker = sr.ticker").
+		Where("sr.deleted_at IS NULL AND sr.time_horizon = ?", horizon)
+	if sector != "" {
+		query = query.Where("LOWER(ts.sector) = LOWER(?)", sector)
+	}
+
+	var summaries []models.SectorSummary
+	if err := query.Group("ts.sector").Order("ts.sector").Scan(&summaries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sector stats: %w", err)
+	}
+	return summaries, nil
+}
+
+// GetSectorActivity counts the upgrades and downgrades published in each sector since the given
+// time. An empty sector returns every sector.
+func (r *sectorRepository) GetSectorActivity(since time.Time, sector string) ([]models.SectorSummary, error) {
+	query := r.db.Table("stocks AS s").
+		Select(`ts.sector,
+			SUM(CASE WHEN s.action ILIKE '%upgrade%' THEN 1 ELSE 0 END) AS upgrade_count,
+			SUM(CASE WHEN s.action ILIKE '%downgrade%' THEN 1 ELSE 0 END) AS downgrade_count`).
+		Joins("JOIN ticker_sectors AS ts ON ts.ticker = s.ticker").
+		Where("s.deleted_at IS NULL AND s.time >= ?", since)
+	if sector != "" {
+		query = query.Where("LOWER(ts.sector) = LOWER(?)", sector)
+	}
+
+	var activity []models.SectorSummary
+	if err := query.Group("ts.sector").Scan(&activity).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sector activity: %w", err)
+	}
+	return activity, nil
+}
+
+// GetSectorTopPicks retrieves the perSector highest scored recommendations of each sector for a
+// time horizon, with their sector set. An empty sector returns picks for every sector.
+func (r *sectorRepository) GetSectorTopPicks(horizon, sector string, perSector int) ([]models.StockRecommendation, error) {
+	ranked := r.db.Table("stock_recommendations AS sr").
+		Select(`sr.*, ts.sector,
+			ROW_NUMBER() OVER (PARTITION BY ts.sector ORDER BY sr.recommendation_score DESC, sr.ticker) AS sector_rank`).
+		Joins("JOIN ticker_sectors AS ts ON ts.ticker = sr.ticker").
+		Where("sr.deleted_at IS NULL AND sr.time_horizon = ?", horizon)
+	if sector != "" {
+		ranked = ranked.Where("LOWER(ts.sector) = LOWER(?)", sector)
+	}
+
+	var recommendations []models.StockRecommendation
+	if err := r.db.Preload("Stock").Table("(?) AS stock_recommendations", ranked).
+		Where("sector_rank <= ?", perSector).
+		Order("sector, sector_rank").Find(&recommendations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sector top picks: %w", err)
+	}
+	return recommendations, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+	"truora-backend/internal/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+type StatsRepository interface {
+	GetDataVersion() (models.DataVersion, error)
+	GetTotals() (*models.MarketOverview, error)
+	GetActivity(since time.Time) (*models.ActivityWindow, error)
+	GetMostActiveBrokerages(since time.Time, limit int) ([]models.BrokerageCount, error)
+	GetMostCoveredTickers(limit int) ([]models.TickerCoverage, error)
+	GetCurrentRatingCounts() (map[string]int, error)
+	GetFreshness() (*models.DataFreshness, error)
+}
+
+type statsRepository struct {
+	db *gorm.DB
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *gorm.DB) StatsRepository {
+	return &statsRepository{db: db}
+}
+
+// GetDataVersion returns when an analyst event was last stored or updated and when the last
+// completed recommendation run finished, which change with every ingestion and run
+func (r *statsRepository) GetDataVersion() (models.DataVersion, error) {
+	var version models.DataVersion
+	if err := r.db.Raw(`SELECT
+			(SELECT MAX(last_updated) FROM stocks WHERE deleted_at IS NULL) AS last_ingested,
+			(SELECT MAX(finished_at) FROM recommendation_runs WHERE status = ?) AS last_recommendation`, models.RunStatusCompleted).
+		Scan(&version).Error; err != nil {
+		return models.DataVersion{}, fmt.Errorf("failed to get data version: %w", err)
+	}
+	return version, nil
+}
+
+// GetTotals returns an overview with only its totals set: the distinct tickers, the events and the
+// distinct brokerages stored
+func (r *statsRepository) GetTotals() (*models.MarketOverview, error) {
+	var totals struct {
+		TotalTickers    int
+		TotalEvents     int
+		TotalBrokerages int
+	}
+	if err := r.db.Model(&models.Stock{}).
+		Select("COUNT(DISTINCT ticker) AS total_tickers, COUNT(*) AS total_events, COUNT(DISTINCT NULLIF(brokerage, '')) AS total_brokerages").
+		Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get totals: %w", err)
+	}
+	return &models.MarketOverview{
+		TotalTickers:    totals.TotalTickers,
+		TotalEvents:     totals.TotalEvents,
+		TotalBrokerages: totals.TotalBrokerages,
+	}, nil
+}
+
+// GetActivity counts the events, upgrades and downgrades published since the given time
+func (r *statsRepository) GetActivity(since time.Time) (*models.ActivityWindow, error) {
+	var activity models.ActivityWindow
+	if err := r.db.Model(&models.Stock{}).
+		Select(`COUNT(*) AS events,
+			COALESCE(SUM(CASE WHEN action ILIKE '%upgrade%' THEN 1 ELSE 0 END), 0) AS upgrades,
+			COALESCE(SUM(CASE WHEN action ILIKE '%downgrade%' THEN 1 ELSE 0 END), 0) AS downgrades`).
+		Where("time >= ?", since).
+		Scan(&activity).Error; err != nil {
+		return nil, fmt.Errorf("failed to get activity: %w", err)
+	}
+	return &activity, nil
+}
+
+// GetMostActiveBrokerages retrieves the brokerages that published the most events since the given
+// time, most active first
+func (r *statsRepository) GetMostActiveBrokerages(since time.Time, limit int) ([]models.BrokerageCount, error) {
+	var counts []models.BrokerageCount
+	if err := r.db.Model(&models.Stock{}).
+		Select("brokerage AS name, COUNT(*) AS event_count").
+		Where("time >= ? AND brokerage <> ''", since).
+		Group("brokerage").
+		Order("event_count DESC, name").
+		Limit(limit).Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get most active brokerages: %w", err)
+	}
+	return counts, nil
+}
+
+// GetMostCoveredTickers retrieves the tickers covered by the most distinct brokerages
+func (r *statsRepository) GetMostCoveredTickers(limit int) ([]models.TickerCoverage, error) {
+	var coverage []models.TickerCoverage
+	if err := r.db.Model(&models.Stock{}).
+		Select("ticker, MAX(company) AS company, COUNT(DISTINCT brokerage) AS brokerage_count, COUNT(*) AS event_count").
+		Group("ticker").
+		Order("brokerage_count DESC, event_count DESC, ticker").
+		Limit(limit).Scan(&coverage).Error; err != nil {
+		return nil, fmt.Errorf("failed to get most covered tickers: %w", err)
+	}
+	return coverage, nil
+}
+
+// GetCurrentRatingCounts counts the current ratings, the latest rating_to of every ticker and
+// brokerage pair, by rating
+func (r *statsRepository) GetCurrentRatingCounts() (map[string]int, error) {
+	latest := r.db.Model(&models.Stock{}).
+		Select("DISTINCT ON (ticker, brokerage) rating_to").
+		Order("ticker, brokerage, time DESC")
+
+	var rows []struct {
+		RatingTo string
+		Count    int
+	}
+	if err := r.db.Table("(?) AS latest", latest).
+		Select("rating_to, COUNT(*) AS count").
+		Group("rating_to").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get rating counts: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.RatingTo] = row.Count
+	}
+	return counts, nil
+}
+
+// GetFreshness returns the times of the newest event, ingestion, completed recommendation run and
+// stored close
+func (r *statsRepository) GetFreshness() (*models.DataFreshness, error) {
+	var freshness models.DataFreshness
+	if err := r.db.Raw(`SELECT
+			(SELECT MAX(time) FROM stocks WHERE deleted_at IS NULL) AS latest_event,
+			(SELECT MAX(last_updated) FROM stocks WHERE deleted_at IS NULL) AS last_ingested,
+			(SELECT MAX(finished_at) FROM recommendation_runs WHERE status = ?) AS last_recommendation,
+			(SELECT MAX(date) FROM stock_prices) AS latest_price`, models.RunStatusCompleted).
+		Scan(&freshness).Error; err != nil {
+		return nil, fmt.Errorf("failed to get data freshness: %w", err)
+	}
+	return &freshness, nil
+}
@@ -1,21 +1,45 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 	"truora-backend/internal/pkg/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type StockRepository interface {
 	Create(stock *models.Stock) error
-	GetByTicker(ticker string) (*models.Stock, error)
+	GetEventsByTicker(ticker string) ([]models.Stock, error)
+	GetLatestEventsByBrokerage(ticker string) ([]models.Stock, error)
+	GetTimeline(ticker string, filter models.TimelineFilter) ([]models.Stock, error)
 	GetAll(limit, offset int) ([]models.Stock, error)
+	GetStocks(filter models.StockFilter, withTotal bool) ([]models.Stock, int64, error)
+	CountStocks(filter models.StockFilter) (int64, error)
+	StreamStocks(ctx context.Context, filter models.StockFilter, withUpside bool, fn func(stock models.Stock) error) error
 	Update(stock *models.Stock) error
 	Delete(id uint) error
 	BulkCreate(stocks []models.Stock) error
-	GetTopRecommendations(limit int) ([]models.StockRecommendation, error)
+	GetTopRecommendations(filter models.RecommendationFilter) ([]models.StockRecommendation, error)
+	CountRecommendations(filter models.RecommendationFilter) (int64, error)
+	GetRecommendationByTicker(ticker, horizon string) (*models.StockRecommendation, error)
+	StreamRecommendations(ctx context.Context, filter models.RecommendationFilter, fn func(recommendation models.StockRecommendation) error) error
 	CreateRecommendation(recommendation *models.StockRecommendation) error
+	UpsertRecommendations(ctx context.Context, recommendations []*models.StockRecommendation) error
+	GetTickersUpdatedSince(since time.Time) ([]string, error)
+	StreamTickerEvents(ctx context.Context, tickers []string, fn func(ticker string, events []models.Stock) error) error
+	GetLastCompletedRun() (*models.RecommendationRun, error)
+	GetRecommendationRuns(ctx context.Context) ([]models.RecommendationRun, error)
+	CreateRecommendationRun(run *models.RecommendationRun) error
+	UpdateRecommendationRun(run *models.RecommendationRun) error
+	CalibrateRecommendations(ctx context.Context, gradeCutoffs []float64) error
+	CreateRankSnapshots(ctx context.Context, runID uint, takenAt time.Time) error
+	GetRankHistory(ticker, horizon string, since time.Time) ([]models.RecommendationSnapshot, error)
+	GetTopMovers(since time.Time, filter models.RecommendationFilter) ([]models.StockRecommendation, error)
 	GetStockCount() (int64, error)
 	SearchStocks(query string, limit, offset int) ([]models.Stock, error)
 }
@@ -37,29 +61,282 @@ func (r *stockRepository) Create(stock *models.Stock) error {
 	return nil
 }
 
-// GetByTicker retrieves a stock by its ticker
-func (r *stockRepository) GetByTicker(ticker string) (*models.Stock, error) {
-	var stock models.Stock
-	if err := r.db.Where("ticker = ?", ticker).First(&stock).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("failed to get stock by ticker: %w", err)
+// GetEventsByTicker retrieves every analyst event for a ticker, newest first
+func (r *stockRepository) GetEventsByTicker(ticker string) ([]models.Stock, error) {
+	var stocks []models.Stock
+	if err := r.db.Where("ticker = ?", ticker).Order("time DESC").Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get events by ticker: %w", err)
 	}
-	return &stock, nil
+	return stocks, nil
 }
 
-// GetAll retrieves all stocks with pagination
-func (r *stockRepository) GetAll(limit, offset int) ([]models.Stock, error) {
+// GetLatestEventsByBrokerage retrieves the latest analyst event of every brokerage covering a
+// ticker, newest first. Brokerage names are compared ignoring case and surrounding spaces.
+func (r *stockRepository) GetLatestEventsByBrokerage(ticker string) ([]models.Stock, error) {
+	latest := r.db.Model(&models.Stock{}).
+		Select("DISTINCT ON (LOWER(TRIM(brokerage))) *").
+		Where("ticker = ?", ticker).
+		Order("LOWER(TRIM(brokerage)), time DESC, id DESC")
+
+	var stocks []models.Stock
+	if err := r.db.Table("(?) AS stocks", latest).Order("time DESC").Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get latest events by brokerage: %w", err)
+	}
+	return stocks, nil
+}
+
+// GetTimeline retrieves the analyst events of a ticker matching a timeline filter, oldest first
+func (r *stockRepository) GetTimeline(ticker string, filter models.TimelineFilter) ([]models.Stock, error) {
+	query := r.db.Where("ticker = ?", ticker)
+	if filter.Brokerage != "" {
+		query = query.Where("LOWER(brokerage) = LOWER(?)", filter.Brokerage)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("time >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("time < ?", filter.To)
+	}
+
 	var stocks []models.Stock
-	query := r.db.Limit(limit).Offset(offset)
-	
-	if err := query.Find(&stocks).Error; err != nil {
-		return nil, fmt.Errorf("failed to get all stocks: %w", err)
+	if err := query.Order("time ASC, id ASC").Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get timeline: %w", err)
 	}
 	return stocks, nil
 }
 
+// GetAll retrieves all stocks with pagination, newest first
+func (r *stockRepository) GetAll(limit, offset int) ([]models.Stock, error) {
+	stocks, _, err := r.GetStocks(models.StockFilter{Limit: limit, Offset: offset}, false)
+	return stocks, err
+}
+
+// stockUpside is the percent of an event's numeric target over the ticker's latest close, rounded
+// so cursors carry it exactly
+const stockUpside = "ROUND((stocks.target_to_value - latest_prices.close) / NULLIF(latest_prices.close, 0) * 100, 4)"
+
+// stockSortColumns maps the sortable fields of a stocks listing to the expressions they order by
+var stockSortColumns = map[string]string{
+	"time":      "stocks.time",
+	"ticker":    "stocks.ticker",
+	"company":   "stocks.company",
+	"brokerage": "stocks.brokerage",
+	"rating_to": "stocks.rating_to",
+	"target":    "stocks.target_to_value",
+	"upside":    stockUpside,
+}
+
+// stockRow is a stocks listing row with the window count of every matching row
+type stockRow struct {
+	models.Stock
+	TotalCount int64
+}
+
+// GetStocks retrieves the analyst events matching a filter in the filter's sort order. With
+// withTotal it also returns how many events match the filter: counted by a window function in the
+// same query on offset pages, and by a separate count on cursor pages or past the last row.
+func (r *stockRepository) GetStocks(filter models.StockFilter, withTotal bool) ([]models.Stock, int64, error) {
+	columns := []string{"stocks.*"}
+	query := applyStockFilter(r.db.Model(&models.Stock{}), filter)
+	for _, key := range filter.Sort {
+		if key.Field == "upside" {
+			columns = append(columns, stockUpside+" AS upside")
+			query = joinLatestPrices(query)
+			break
+		}
+	}
+	windowTotal := withTotal && filter.After == nil
+	if windowTotal {
+		columns = append(columns, "COUNT(*) OVER() AS total_count")
+	}
+
+	query, err := applyStockSort(query.Select(strings.Join(columns, ", ")), filter.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.After != nil {
+		if query, err = applyStockCursor(query, filter); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		query = query.Offset(filter.Offset)
+	}
+
+	var rows []stockRow
+	if err := query.Limit(filter.Limit).Find(&rows).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get stocks: %w", err)
+	}
+	stocks := make([]models.Stock, len(rows))
+	for i, row := range rows {
+		stocks[i] = row.Stock
+	}
+
+	if !withTotal {
+		return stocks, 0, nil
+	}
+	if windowTotal && len(rows) > 0 {
+		return stocks, rows[0].TotalCount, nil
+	}
+	total, err := r.CountStocks(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return stocks, total, nil
+}
+
+// StreamStocks calls fn with every analyst event matching a filter in the filter's sort order,
+// with upside when withUpside is set. Rows are read one at a time; the filter's pagination is
+// ignored. Latest prices are only joined when the upside is read or sorted on.
+func (r *stockRepository) StreamStocks(ctx context.Context, filter models.StockFilter, withUpside bool, fn func(stock models.Stock) error) error {
+	columns := "stocks.*"
+	query := applyStockFilter(r.db.WithContext(ctx).Model(&models.Stock{}), filter)
+	joinPrices := withUpside
+	for _, key := range filter.Sort {
+		joinPrices = joinPrices || key.Field == "upside"
+	}
+	if joinPrices {
+		query = joinLatestPrices(query)
+	}
+	if withUpside {
+		columns += ", " + stockUpside + " AS upside"
+	}
+
+	query, err := applyStockSort(query.Select(columns), filter.Sort)
+	if err != nil {
+		return err
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return fmt.Errorf("failed to stream stocks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stock models.Stock
+		if err := r.db.ScanRows(rows, &stock); err != nil {
+			return fmt.Errorf("failed to scan stock: %w", err)
+		}
+		if err := fn(stock); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream stocks: %w", err)
+	}
+	return nil
+}
+
+// joinLatestPrices joins the latest close of each event's ticker, which stockUpside reads
+func joinLatestPrices(query *gorm.DB) *gorm.DB {
+	return query.Joins(`LEFT JOIN (SELECT DISTINCT ON (ticker) ticker, close FROM stock_prices ORDER BY ticker, date DESC) AS latest_prices
+		ON latest_prices.ticker = stocks.ticker`)
+}
+
+// applyStockSort orders a stocks query by sort keys, newest first when there are none. Events
+// without a numeric target or price sort last in either direction, and the event id breaks ties
+// so pages never overlap.
+func applyStockSort(query *gorm.DB, keys []models.SortKey) (*gorm.DB, error) {
+	if len(keys) == 0 {
+		keys = models.DefaultStockSort
+	}
+
+	direction := "ASC"
+	for _, key := range keys {
+		column, ok := stockSortColumns[key.Field]
+		if !ok {
+			return nil, fmt.Errorf("unsupported sort field %q", key.Field)
+		}
+		direction = "ASC"
+		if key.Desc {
+			direction = "DESC"
+		}
+		if key.Field == "target" || key.Field == "upside" {
+			query = query.Order("(" + column + ") IS NULL")
+		}
+		query = query.Order(column + " " + direction)
+	}
+	return query.Order("stocks.id " + direction), nil
+}
+
+// applyStockCursor narrows a sorted stocks query to the rows after the filter's cursor
+func applyStockCursor(query *gorm.DB, filter models.StockFilter) (*gorm.DB, error) {
+	keys := filter.Sort
+	if len(keys) == 0 {
+		keys = models.DefaultStockSort
+	}
+	if !filter.After.Matches(keys) {
+		return nil, fmt.Errorf("%w: the sort does not match the cursor", models.ErrInvalidCursor)
+	}
+
+	columns := make([]keysetColumn, 0, len(keys))
+	for i, key := range keys {
+		column := keysetColumn{expr: stockSortColumns[key.Field], desc: key.Desc, value: filter.After.Values[i]}
+		var err error
+		switch key.Field {
+		case "time":
+			column.value, err = cursorTime(column.value)
+		case "target", "upside":
+			column.nullable = true
+			column.value, err = cursorNumber(column.value, true)
+		default:
+			if _, ok := column.value.(string); !ok {
+				err = fmt.Errorf("%w: expected text", models.ErrInvalidCursor)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	condition, args := keysetCondition(columns, "stocks.id", keys[len(keys)-1].Desc, filter.After.ID)
+	return query.Where(condition, args...), nil
+}
+
+// CountStocks returns the number of analyst events matching a filter
+func (r *stockRepository) CountStocks(filter models.StockFilter) (int64, error) {
+	var count int64
+	if err := applyStockFilter(r.db.Model(&models.Stock{}), filter).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count stocks: %w", err)
+	}
+	return count, nil
+}
+
+// applyStockFilter narrows a stocks query to a filter's conditions. Brokerage, action and ratings
+// match case-insensitively, like the timeline's brokerage, on their LOWER expression indexes.
+func applyStockFilter(query *gorm.DB, filter models.StockFilter) *gorm.DB {
+	if filter.Query != "" {
+		searchQuery := "%" + filter.Query + "%"
+		query = query.Where("stocks.ticker ILIKE ? OR stocks.company ILIKE ?", searchQuery, searchQuery)
+	}
+	if filter.Brokerage != "" {
+		query = query.Where("LOWER(stocks.brokerage) = LOWER(?)", filter.Brokerage)
+	}
+	if filter.Action != "" {
+		query = query.Where("LOWER(stocks.action) = LOWER(?)", filter.Action)
+	}
+	if filter.RatingFrom != "" {
+		query = query.Where("LOWER(stocks.rating_from) = LOWER(?)", filter.RatingFrom)
+	}
+	if filter.RatingTo != "" {
+		query = query.Where("LOWER(stocks.rating_to) = LOWER(?)", filter.RatingTo)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("stocks.time >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("stocks.time < ?", filter.To)
+	}
+	if filter.MinTarget != nil {
+		query = query.Where("stocks.target_to_value >= ?", *filter.MinTarget)
+	}
+	if filter.MaxTarget != nil {
+		query = query.Where("stocks.target_to_value <= ?", *filter.MaxTarget)
+	}
+	return query
+}
+
 // Update updates an existing stock record
 func (r *stockRepository) Update(stock *models.Stock) error {
 	if err := r.db.Save(stock).Error; err != nil {
@@ -76,37 +353,202 @@ func (r *stockRepository) Delete(id uint) error {
 	return nil
 }
 
-// BulkCreate creates multiple stock records in a single transaction
+// stockEventColumns are the columns of an analyst event a re-fetch may change
+var stockEventColumns = []string{
+	"company", "target_from", "target_to", "target_from_value", "target_to_value",
+	"action", "rating_from", "rating_to",
+}
+
+// BulkCreate stores analyst events, updating the stored event of the same ticker, brokerage and
+// time instead of duplicating it. A stored event is only rewritten, and its updated_at moved,
+// when a column changed, so re-fetching the feed does not mark every ticker as updated. A repeated
+// event within stocks replaces the earlier one, as one upsert cannot touch a row twice.
 func (r *stockRepository) BulkCreate(stocks []models.Stock) error {
 	if len(stocks) == 0 {
 		return nil
 	}
 
-	// Use batch insert for better performance
-	batchSize := 100
-	for i := 0; i < len(stocks); i += batchSize {
-		end := i + batchSize
-		if end > len(stocks) {
-			end = len(stocks)
-		}
-		
-		batch := stocks[i:end]
-		if err := r.db.CreateInBatches(batch, batchSize).Error; err != nil {
-			return fmt.Errorf("failed to bulk create stocks: %w", err)
+	seen := make(map[string]int)
+	events := make([]models.Stock, 0, len(stocks))
+	for _, stock := range stocks {
+		key := stock.Ticker + "|" + stock.Brokerage + "|" + stock.Time.UTC().Format(time.RFC3339Nano)
+		if i, ok := seen[key]; ok {
+			events[i] = stock
+			continue
 		}
+		seen[key] = len(events)
+		events = append(events, stock)
+	}
+
+	changed := make([]string, 0, len(stockEventColumns))
+	for _, column := range stockEventColumns {
+		changed = append(changed, "stocks."+column+" IS DISTINCT FROM excluded."+column)
+	}
+	updates := append(append([]string{}, stockEventColumns...), "last_updated", "updated_at")
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ticker"}, {Name: "brokerage"}, {Name: "time"}},
+		DoUpdates: clause.AssignmentColumns(updates),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: strings.Join(changed, " OR ")}}},
+	}).CreateInBatches(events, 100).Error
+	if err != nil {
+		return fmt.Errorf("failed to bulk create stocks: %w", err)
 	}
 	return nil
 }
 
-// GetTopRecommendations retrieves top stock recommendations
-func (r *stockRepository) GetTopRecommendations(limit int) ([]models.StockRecommendation, error) {
+// recommendationSortColumns maps the sortable fields of a recommendation listing to their columns
+var recommendationSortColumns = map[string]string{
+	"score":  "stock_recommendations.recommendation_score",
+	"return": "stock_recommendations.expected_return",
+	"risk":   "stock_recommendations.risk_score",
+}
+
+// GetTopRecommendations retrieves the stock recommendations matching a filter in the filter's
+// sort order, best score first when it has none
+func (r *stockRepository) GetTopRecommendations(filter models.RecommendationFilter) ([]models.StockRecommendation, error) {
+	keys := filter.Sort
+	if len(keys) == 0 {
+		keys = models.RecommendationScoreSort
+	}
+
+	query, err := applyRecommendationSort(applyRecommendationFilter(r.db.Preload("Stock"), filter), keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.After != nil {
+		if !filter.After.Matches(keys) {
+			return nil, fmt.Errorf("%w: the sort does not match the cursor", models.ErrInvalidCursor)
+		}
+		columns := make([]keysetColumn, 0, len(keys))
+		for i, key := range keys {
+			value, err := cursorNumber(filter.After.Values[i], false)
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, keysetColumn{expr: recommendationSortColumns[key.Field], desc: key.Desc, value: value})
+		}
+		condition, args := keysetCondition(columns, "stock_recommendations.id", keys[len(keys)-1].Desc, filter.After.ID)
+		query = query.Where(condition, args...)
+	} else {
+		query = query.Offset(filter.Offset)
+	}
+
 	var recommendations []models.StockRecommendation
-	if err := r.db.Preload("Stock").Order("recommendation_score DESC").Limit(limit).Find(&recommendations).Error; err != nil {
+	if err := query.Limit(filter.Limit).Find(&recommendations).Error; err != nil {
 		return nil, fmt.Errorf("failed to get top recommendations: %w", err)
 	}
 	return recommendations, nil
 }
 
+// CountRecommendations counts the recommendations matching a filter, ignoring its pagination
+func (r *stockRepository) CountRecommendations(filter models.RecommendationFilter) (int64, error) {
+	var count int64
+	if err := applyRecommendationFilter(r.db.Model(&models.StockRecommendation{}), filter).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count recommendations: %w", err)
+	}
+	return count, nil
+}
+
+// StreamRecommendations calls fn with every recommendation matching a filter in the filter's sort
+// order, without the preloaded stock. Rows are read one at a time; the filter's pagination is
+// ignored.
+func (r *stockRepository) StreamRecommendations(ctx context.Context, filter models.RecommendationFilter,
+	fn func(recommendation models.StockRecommendation) error) error {
+	keys := filter.Sort
+	if len(keys) == 0 {
+		keys = models.RecommendationScoreSort
+	}
+	query, err := applyRecommendationSort(applyRecommendationFilter(r.db.WithContext(ctx).Model(&models.StockRecommendation{}), filter), keys)
+	if err != nil {
+		return err
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return fmt.Errorf("failed to stream recommendations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recommendation models.StockRecommendation
+		if err := r.db.ScanRows(rows, &recommendation); err != nil {
+			return fmt.Errorf("failed to scan recommendation: %w", err)
+		}
+		if err := fn(recommendation); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream recommendations: %w", err)
+	}
+	return nil
+}
+
+// applyRecommendationSort orders a stock_recommendations query by sort keys, with the
+// recommendation id breaking ties in the last key's direction
+func applyRecommendationSort(query *gorm.DB, keys []models.SortKey) (*gorm.DB, error) {
+	direction := "ASC"
+	for _, key := range keys {
+		column, ok := recommendationSortColumns[key.Field]
+		if !ok {
+			return nil, fmt.Errorf("unsupported sort field %q", key.Field)
+		}
+		direction = "ASC"
+		if key.Desc {
+			direction = "DESC"
+		}
+		query = query.Order(column + " " + direction)
+	}
+	return query.Order("stock_recommendations.id " + direction), nil
+}
+
+// GetRecommendationByTicker retrieves a ticker's current recommendation for a time horizon, or nil
+// when it has none
+func (r *stockRepository) GetRecommendationByTicker(ticker, horizon string) (*models.StockRecommendation, error) {
+	var recommendation models.StockRecommendation
+	if err := r.db.Where("ticker = ? AND time_horizon = ?", ticker, horizon).First(&recommendation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get recommendation by ticker: %w", err)
+	}
+	return &recommendation, nil
+}
+
+// applyRecommendationFilter narrows a stock_recommendations query to a filter's conditions
+func applyRecommendationFilter(query *gorm.DB, filter models.RecommendationFilter) *gorm.DB {
+	if filter.TimeHorizon != "" {
+		query = query.Where("stock_recommendations.time_horizon = ?", filter.TimeHorizon)
+	}
+	if filter.Sector != "" {
+		query = query.Where("stock_recommendations.ticker IN (SELECT ticker FROM ticker_sectors WHERE LOWER(sector) = LOWER(?))", filter.Sector)
+	}
+	if filter.RiskLevel != "" {
+		query = query.Where("stock_recommendations.risk_level = ?", filter.RiskLevel)
+	}
+	if filter.Sentiment != "" {
+		query = query.Where("stock_recommendations.analyst_sentiment = ?", filter.Sentiment)
+	}
+	if filter.Query != "" {
+		query = query.Where("stock_recommendations.ticker ILIKE ?", "%"+filter.Query+"%")
+	}
+	if filter.MinScore > 0 {
+		query = query.Where("stock_recommendations.recommendation_score >= ?", filter.MinScore)
+	}
+	if filter.MaxScore != nil {
+		query = query.Where("stock_recommendations.recommendation_score <= ?", *filter.MaxScore)
+	}
+	if filter.MinReturn != nil {
+		query = query.Where("stock_recommendations.expected_return >= ?", *filter.MinReturn)
+	}
+	if filter.MinConfidence > 0 {
+		query = query.Where("stock_recommendations.confidence >= ?", filter.MinConfidence)
+	}
+	return query
+}
+
 // CreateRecommendation creates a new stock recommendation
 func (r *stockRepository) CreateRecommendation(recommendation *models.StockRecommendation) error {
 	if err := r.db.Create(recommendation).Error; err != nil {
@@ -115,6 +557,244 @@ func (r *stockRepository) CreateRecommendation(recommendation *models.StockRecom
 	return nil
 }
 
+// recommendationScoreColumns are the columns a recompute sets on a recommendation; calibration
+// columns are left to CalibrateRecommendations
+var recommendationScoreColumns = []string{
+	"stock_id", "recommendation_score", "score_lower", "score_upper", "confidence", "risk_level", "risk_score",
+	"expected_return", "expected_return_low", "expected_return_high", "upside", "reference_price",
+	"target_count", "target_mean", "target_median", "target_high", "target_low", "target_change",
+	"reason", "analyst_sentiment", "consensus_rating", "brokerage_count", "upgrade_count", "downgrade_count",
+}
+
+// UpsertRecommendations stores a batch of recommendations, updating the stored recommendation of
+// the same ticker and horizon in place so its id, which cursors break ties on, survives a
+// recompute. Unchanged recommendations are not rewritten; deleted ones are restored.
+func (r *stockRepository) UpsertRecommendations(ctx context.Context, recommendations []*models.StockRecommendation) error {
+	if len(recommendations) == 0 {
+		return nil
+	}
+
+	changed := []string{"stock_recommendations.deleted_at IS NOT NULL"}
+	for _, column := range recommendationScoreColumns {
+		changed = append(changed, "stock_recommendations."+column+" IS DISTINCT FROM excluded."+column)
+	}
+	updates := append(append([]string{}, recommendationScoreColumns...), "updated_at", "deleted_at")
+
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ticker"}, {Name: "time_horizon"}},
+		DoUpdates: clause.AssignmentColumns(updates),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: strings.Join(changed, " OR ")}}},
+	}).CreateInBatches(recommendations, len(recommendations)).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert recommendations: %w", err)
+	}
+	return nil
+}
+
+// GetTickersUpdatedSince returns tickers with events created, updated or deleted after since
+func (r *stockRepository) GetTickersUpdatedSince(since time.Time) ([]string, error) {
+	var tickers []string
+	if err := r.db.Unscoped().Model(&models.Stock{}).
+		Where("updated_at > ? OR deleted_at > ?", since, since).
+		Distinct().Pluck("ticker", &tickers).Error; err != nil {
+		return nil, fmt.Errorf("failed to get updated tickers: %w", err)
+	}
+	return tickers, nil
+}
+
+// StreamTickerEvents reads events ticker by ticker and calls fn with each ticker's events,
+// newest first. Only one ticker's events are held in memory at a time. A nil tickers slice
+// streams every ticker.
+func (r *stockRepository) StreamTickerEvents(ctx context.Context, tickers []string, fn func(ticker string, events []models.Stock) error) error {
+	query := r.db.WithContext(ctx).Model(&models.Stock{}).Order("ticker, time DESC, id")
+	if tickers != nil {
+		if len(tickers) == 0 {
+			return nil
+		}
+		query = query.Where("ticker IN ?", tickers)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return fmt.Errorf("failed to stream stock events: %w", err)
+	}
+	defer rows.Close()
+
+	var current string
+	var events []models.Stock
+	for rows.Next() {
+		var stock models.Stock
+		if err := r.db.ScanRows(rows, &stock); err != nil {
+			return fmt.Errorf("failed to scan stock event: %w", err)
+		}
+
+		if stock.Ticker != current && len(events) > 0 {
+			if err := fn(current, events); err != nil {
+				return err
+			}
+			events = nil
+		}
+		current = stock.Ticker
+		events = append(events, stock)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream stock events: %w", err)
+	}
+
+	if len(events) > 0 {
+		return fn(current, events)
+	}
+	return nil
+}
+
+// GetLastCompletedRun returns the most recent successful recommendation run, or nil if none exists
+func (r *stockRepository) GetLastCompletedRun() (*models.RecommendationRun, error) {
+	var run models.RecommendationRun
+	if err := r.db.Where("status = ?", models.RunStatusCompleted).
+		Order("started_at DESC").First(&run).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get last recommendation run: %w", err)
+	}
+	return &run, nil
+}
+
+// GetRecommendationRuns returns every recommendation run, oldest first
+func (r *stockRepository) GetRecommendationRuns(ctx context.Context) ([]models.RecommendationRun, error) {
+	var runs []models.RecommendationRun
+	if err := r.db.WithContext(ctx).Order("started_at ASC, id ASC").Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get recommendation runs: %w", err)
+	}
+	return runs, nil
+}
+
+// CreateRecommendationRun creates a new recommendation run record
+func (r *stockRepository) CreateRecommendationRun(run *models.RecommendationRun) error {
+	if err := r.db.Create(run).Error; err != nil {
+		return fmt.Errorf("failed to create recommendation run: %w", err)
+	}
+	return nil
+}
+
+// UpdateRecommendationRun updates an existing recommendation run record
+func (r *stockRepository) UpdateRecommendationRun(run *models.RecommendationRun) error {
+	if err := r.db.Save(run).Error; err != nil {
+		return fmt.Errorf("failed to update recommendation run: %w", err)
+	}
+	return nil
+}
+
+// CalibrateRecommendations sets every recommendation's percentile rank and z-score among the
+// scores of its horizon, and its grade from the percentile cutoffs of grades A to D. Grades are
+// cleared when no cutoffs are given. Only rows whose calibration changed are written.
+func (r *stockRepository) CalibrateRecommendations(ctx context.Context, gradeCutoffs []float64) error {
+	grade := "NULL::VARCHAR"
+	var args []interface{}
+	if len(gradeCutoffs) > 0 {
+		var cases strings.Builder
+		cases.WriteString("CASE")
+		for i, cutoff := range gradeCutoffs {
+			cases.WriteString(" WHEN scored.percentile >= ? THEN ?")
+			args = append(args, cutoff, models.Grades[i])
+		}
+		cases.WriteString(" ELSE ? END")
+		args = append(args, models.Grades[len(gradeCutoffs)])
+		grade = cases.String()
+	}
+
+	err := r.db.WithContext(ctx).Exec(`UPDATE stock_recommendations
+		SET percentile = ranked.percentile, z_score = ranked.z_score, grade = ranked.grade
+		FROM (
+			SELECT id, percentile, z_score, `+grade+` AS grade
+			FROM (
+				SELECT id,
+					ROUND((PERCENT_RANK() OVER horizon * 100)::DECIMAL, 2) AS percentile,
+					ROUND(COALESCE((recommendation_score - AVG(recommendation_score) OVER horizon)
+						/ NULLIF(STDDEV_POP(recommendation_score) OVER horizon, 0), 0)::DECIMAL, 3) AS z_score
+				FROM stock_recommendations
+				WHERE deleted_at IS NULL
+				WINDOW horizon AS (PARTITION BY time_horizon ORDER BY recommendation_score
+					RANGE BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+			) AS scored
+		) AS ranked
+		WHERE stock_recommendations.id = ranked.id
+			AND (stock_recommendations.percentile IS DISTINCT FROM ranked.percentile
+				OR stock_recommendations.z_score IS DISTINCT FROM ranked.z_score
+				OR stock_recommendations.grade IS DISTINCT FROM ranked.grade)`, args...).Error
+	if err != nil {
+		return fmt.Errorf("failed to calibrate recommendations: %w", err)
+	}
+	return nil
+}
+
+// CreateRankSnapshots records the current rank, score and calibration of the recommendations for
+// a run, skipping those unchanged since their latest snapshot; a ticker's history therefore holds
+// one snapshot per run that moved it
+func (r *stockRepository) CreateRankSnapshots(ctx context.Context, runID uint, takenAt time.Time) error {
+	err := r.db.WithContext(ctx).Exec(`INSERT INTO recommendation_snapshots (run_id, ticker, time_horizon, rank, score, percentile, z_score, grade, created_at)
+		SELECT ?, ranked.ticker, ranked.time_horizon, ranked.rank, ranked.score, ranked.percentile, ranked.z_score, ranked.grade, ?
+		FROM (
+			SELECT ticker, time_horizon,
+				ROW_NUMBER() OVER (PARTITION BY time_horizon ORDER BY recommendation_score DESC, ticker) AS rank,
+				recommendation_score AS score, percentile, z_score, grade
+			FROM stock_recommendations
+			WHERE deleted_at IS NULL
+		) AS ranked
+		LEFT JOIN (
+			SELECT DISTINCT ON (ticker, time_horizon) ticker, time_horizon, rank, score, percentile, z_score, grade
+			FROM recommendation_snapshots
+			ORDER BY ticker, time_horizon, created_at DESC, id DESC
+		) AS latest ON latest.ticker = ranked.ticker AND latest.time_horizon = ranked.time_horizon
+		WHERE latest.ticker IS NULL
+			OR latest.rank <> ranked.rank
+			OR latest.score <> ranked.score
+			OR latest.percentile IS DISTINCT FROM ranked.percentile
+			OR latest.z_score IS DISTINCT FROM ranked.z_score
+			OR latest.grade IS DISTINCT FROM ranked.grade`, runID, takenAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to create rank snapshots: %w", err)
+	}
+	return nil
+}
+
+// GetRankHistory retrieves a ticker's rank snapshots taken after since, oldest first. An empty
+// horizon returns the snapshots of every horizon.
+func (r *stockRepository) GetRankHistory(ticker, horizon string, since time.Time) ([]models.RecommendationSnapshot, error) {
+	var snapshots []models.RecommendationSnapshot
+	query := r.db.Where("ticker = ? AND created_at >= ?", ticker, since)
+	if horizon != "" {
+		query = query.Where("time_horizon = ?", horizon)
+	}
+	if err := query.Order("created_at ASC, time_horizon").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("failed to get rank history: %w", err)
+	}
+	return snapshots, nil
+}
+
+// GetTopMovers retrieves the recommendations whose score changed the most since the given time.
+// The baseline is each ticker's last snapshot taken at or before since, or its first snapshot
+// when it entered the rankings later.
+func (r *stockRepository) GetTopMovers(since time.Time, filter models.RecommendationFilter) ([]models.StockRecommendation, error) {
+	baseline := r.db.Raw(`SELECT DISTINCT ON (ticker, time_horizon) ticker, time_horizon, score
+		FROM recommendation_snapshots
+		ORDER BY ticker, time_horizon,
+			(created_at <= @since) DESC,
+			CASE WHEN created_at <= @since THEN created_at END DESC,
+			created_at ASC`, sql.Named("since", since))
+
+	var recommendations []models.StockRecommendation
+	query := applyRecommendationFilter(r.db.Preload("Stock"), filter)
+	if err := query.
+		Select("stock_recommendations.*, stock_recommendations.recommendation_score - baseline.score AS score_delta").
+		Joins("JOIN (?) AS baseline ON baseline.ticker = stock_recommendations.ticker AND baseline.time_horizon = stock_recommendations.time_horizon", baseline).
+		Order("ABS(stock_recommendations.recommendation_score - baseline.score) DESC, stock_recommendations.id").
+		Offset(filter.Offset).Limit(filter.Limit).Find(&recommendations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get top movers: %w", err)
+	}
+	return recommendations, nil
+}
+
 // GetStockCount returns the total number of stocks
 func (r *stockRepository) GetStockCount() (int64, error) {
 	var count int64
@@ -124,14 +804,8 @@ func (r *stockRepository) GetStockCount() (int64, error) {
 	return count, nil
 }
 
-// SearchStocks searches stocks by symbol or company name
+// SearchStocks searches stocks by symbol or company name, newest first
 func (r *stockRepository) SearchStocks(query string, limit, offset int) ([]models.Stock, error) {
-	var stocks []models.Stock
-	searchQuery := "%" + query + "%"
-	
-	if err := r.db.Where("ticker ILIKE ? OR company ILIKE ?", searchQuery, searchQuery).
-		Limit(limit).Offset(offset).Find(&stocks).Error; err != nil {
-		return nil, fmt.Errorf("failed to search stocks: %w", err)
-	}
-	return stocks, nil
-}
\ No newline at end of file
+	stocks, _, err := r.GetStocks(models.StockFilter{Query: query, Limit: limit, Offset: offset}, false)
+	return stocks, err
+}
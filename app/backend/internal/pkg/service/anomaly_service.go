@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/repository"
+)
+
+type AnomalyService interface {
+	DetectAnomalies(ctx context.Context, since time.Time) (int, error)
+	GetAnomalies(filter models.AnomalyFilter) ([]models.Anomaly, error)
+}
+
+type anomalyService struct {
+	repo   repository.AnomalyRepository
+	stocks repository.StockRepository
+	config Config
+}
+
+// NewAnomalyService creates a new anomaly service
+func NewAnomalyService(repo repository.AnomalyRepository, stocks repository.StockRepository, config Config) AnomalyService {
+	return &anomalyService{
+		repo:   repo,
+		stocks: stocks,
+		config: config,
+	}
+}
+
+// DetectAnomalies scans the recent events of every ticker with events added or changed since the
+// given time, or of every ticker when since is zero, and stores the anomalies found. It returns
+// the number of anomalies stored.
+func (s *anomalyService) DetectAnomalies(ctx context.Context, since time.Time) (int, error) {
+	// A nil ticker list streams every ticker
+	var tickers []string
+	if !since.IsZero() {
+		updated, err := s.stocks.GetTickersUpdatedSince(since)
+		if err != nil {
+			return 0, err
+		}
+		if len(updated) == 0 {
+			return 0, nil
+		}
+		tickers = updated
+	}
+
+	now := time.Now()
+	var anomalies []models.Anomaly
+	err := s.stocks.StreamTickerEvents(ctx, tickers, func(ticker string, events []models.Stock) error {
+		anomalies = append(anomalies, detectAnomalies(ticker, events, s.config, now)...)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan events for anomalies: %w", err)
+	}
+
+	if err := s.repo.UpsertAnomalies(ctx, anomalies); err != nil {
+		return 0, err
+	}
+	log.Printf("Detected %d anomalies", len(anomalies))
+	return len(anomalies), nil
+}
+
+// GetAnomalies retrieves stored anomalies matching a filter
+func (s *anomalyService) GetAnomalies(filter models.AnomalyFilter) ([]models.Anomaly, error) {
+	return s.repo.GetAnomalies(filter)
+}
+
+// detectAnomalies finds downgrade bursts, large target cuts, coverage initiations and rating
+// reversals among a ticker's events. Only anomalies ending within the lookback window are kept.
+func detectAnomalies(ticker string, events []models.Stock, config Config, now time.Time) []models.Anomaly {
+	cutoff := now.AddDate(0, 0, -config.AnomalyLookbackDays)
+
+	// Earlier events can still start a burst or a reversal that ends inside the lookback window
+	span := config.AnomalyBurstDays
+	if config.AnomalyReversalDays > span {
+		span = config.AnomalyReversalDays
+	}
+	earliest := cutoff.AddDate(0, 0, -span)
+
+	var recent []models.Stock
+	for _, event := range events {
+		if !event.Time.Before(earliest) {
+			recent = append(recent, event)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].Time.Before(recent[j].Time)
+	})
+
+	var found []models.Anomaly
+	found = append(found, detectDowngradeBursts(recent, config)...)
+	found = append(found, detectTargetCuts(recent, config)...)
+	found = append(found, detectInitiations(recent, config)...)
+	found = append(found, detectReversals(recent, config)...)
+
+	var anomalies []models.Anomaly
+	for _, anomaly := range found {
+		if anomaly.WindowEnd.Before(cutoff) {
+			continue
+		}
+		anomaly.Ticker = ticker
+		anomaly.DetectedAt = now
+		anomalies = append(anomalies, anomaly)
+	}
+	return anomalies
+}
+
+// detectDowngradeBursts flags downgrades from several brokerages clustered within a few days
+func detectDowngradeBursts(events []models.Stock, config Config) []models.Anomaly {
+	var downgrades []models.Stock
+	for _, event := range events {
+		if ratingDirection(event) < 0 {
+			downgrades = append(downgrades, event)
+		}
+	}
+
+	var anomalies []models.Anomaly
+	for _, cluster := range clusterEvents(downgrades, config.AnomalyBurstDays) {
+		brokerages := distinctBrokerages(cluster)
+		if len(brokerages) < config.AnomalyBurstBrokerages {
+			continue
+		}
+
+		severity := models.SeverityMedium
+		if len(brokerages) > config.AnomalyBurstBrokerages || anyRatedAtMost(cluster, 2) {
+			severity = models.SeverityHigh
+		}
+		anomalies = append(anomalies, clusterAnomaly(models.AnomalyDowngradeBurst, severity, cluster, brokerages,
+			fmt.Sprintf("%d brokerages downgraded within %s", len(brokerages), clusterSpan(cluster))))
+	}
+	return anomalies
+}
+
+// detectTargetCuts flags price target cuts of at least AnomalyTargetCut percent, grouping cuts
+// published within a few days of each other
+func detectTargetCuts(events []models.Stock, config Config) []models.Anomaly {
+	var cuts []models.Stock
+	changes := make(map[uint]float64)
+	for _, event := range events {
+		from, okFrom := models.ParseTargetPrice(event.TargetFrom)
+		to, okTo := models.ParseTargetPrice(event.TargetTo)
+		if !okFrom || !okTo {
+			continue
+		}
+		if change := (to - from) / from * 100; change <= -config.AnomalyTargetCut {
+			cuts = append(cuts, event)
+			changes[event.ID] = change
+		}
+	}
+
+	var anomalies []models.Anomaly
+	for _, cluster := range clusterEvents(cuts, config.AnomalyBurstDays) {
+		worst := 0.0
+		for _, event := range cluster {
+			if changes[event.ID] < worst {
+				worst = changes[event.ID]
+			}
+		}
+
+		severity := models.SeverityLow
+		if worst <= -2*config.AnomalyTargetCut {
+			severity = models.SeverityHigh
+		} else if worst <= -1.5*config.AnomalyTargetCut || len(cluster) > 1 {
+			severity = models.SeverityMedium
+		}
+
+		description := fmt.Sprintf("Price target cut by %.1f%%", -worst)
+		if len(cluster) > 1 {
+			description = fmt.Sprintf("%d price target cuts of up to %.1f%% within %s", len(cluster), -worst, clusterSpan(cluster))
+		}
+		anomaly := clusterAnomaly(models.AnomalyTargetCut, severity, cluster, distinctBrokerages(cluster), description)
+		magnitude := clampPercent(worst)
+		anomaly.Magnitude = &magnitude
+		anomalies = append(anomalies, anomaly)
+	}
+	return anomalies
+}
+
+// detectInitiations flags new coverage, with more initiations in a short window being more severe
+func detectInitiations(events []models.Stock, config Config) []models.Anomaly {
+	var initiations []models.Stock
+	for _, event := range events {
+		if isInitiation(event.Action) {
+			initiations = append(initiations, event)
+		}
+	}
+
+	var anomalies []models.Anomaly
+	for _, cluster := range clusterEvents(initiations, config.AnomalyBurstDays) {
+		severity := models.SeverityLow
+		if len(cluster) >= 3 {
+			severity = models.SeverityHigh
+		} else if len(cluster) == 2 {
+			severity = models.SeverityMedium
+		}
+
+		ratings := make([]string, 0, len(cluster))
+		for _, event := range cluster {
+			ratings = append(ratings, fmt.Sprintf("%s (%s)", strings.TrimSpace(event.Brokerage), event.RatingTo))
+		}
+		anomalies = append(anomalies, clusterAnomaly(models.AnomalyCoverageInitiation, severity, cluster,
+			distinctBrokerages(cluster), "Coverage initiated by "+strings.Join(ratings, ", ")))
+	}
+	return anomalies
+}
+
+// detectReversals flags a brokerage turning its call around within AnomalyReversalDays
+func detectReversals(events []models.Stock, config Config) []models.Anomaly {
+	previous := make(map[string]models.Stock)
+	var anomalies []models.Anomaly
+	for _, event := range events {
+		direction := ratingDirection(event)
+		if direction == 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(event.Brokerage))
+		prior, ok := previous[key]
+		previous[key] = event
+		if !ok || ratingDirection(prior) != -direction {
+			continue
+		}
+
+		days := event.Time.Sub(prior.Time).Hours() / 24
+		if days > float64(config.AnomalyReversalDays) {
+			continue
+		}
+
+		severity := models.SeverityMedium
+		if days <= 7 {
+			severity = models.SeverityHigh
+		}
+		cluster := []models.Stock{prior, event}
+		anomalies = append(anomalies, clusterAnomaly(models.AnomalyRatingReversal, severity, cluster,
+			distinctBrokerages(cluster), fmt.Sprintf("%s reversed from %s to %s within %s",
+				strings.TrimSpace(event.Brokerage), prior.RatingTo, event.RatingTo, clusterSpan(cluster))))
+	}
+	return anomalies
+}
+
+// clusterEvents groups time-ordered events into runs that start at an event and take every
+// following event within windowDays of it
+func clusterEvents(events []models.Stock, windowDays int) [][]models.Stock {
+	var clusters [][]models.Stock
+	for i := 0; i < len(events); {
+		end := events[i].Time.AddDate(0, 0, windowDays)
+		j := i + 1
+		for j < len(events) && !events[j].Time.After(end) {
+			j++
+		}
+		clusters = append(clusters, events[i:j])
+		i = j
+	}
+	return clusters
+}
+
+// clusterAnomaly builds an anomaly covering a time-ordered cluster of events
+func clusterAnomaly(anomalyType, severity string, cluster []models.Stock, brokerages []string, description string) models.Anomaly {
+	return models.Anomaly{
+		Type:        anomalyType,
+		Severity:    severity,
+		Description: description,
+		Brokerages:  strings.Join(brokerages, ", "),
+		EventCount:  len(cluster),
+		WindowStart: cluster[0].Time,
+		WindowEnd:   cluster[len(cluster)-1].Time,
+	}
+}
+
+// distinctBrokerages lists the brokerages of events in order of first appearance
+func distinctBrokerages(events []models.Stock) []string {
+	seen := make(map[string]bool)
+	var brokerages []string
+	for _, event := range events {
+		name := strings.TrimSpace(event.Brokerage)
+		key := strings.ToLower(name)
+		if !seen[key] {
+			seen[key] = true
+			brokerages = append(brokerages, name)
+		}
+	}
+	return brokerages
+}
+
+// anyRatedAtMost reports whether any event's new rating is at or below value on the 1-5 scale
+func anyRatedAtMost(events []models.Stock, value float64) bool {
+	for _, event := range events {
+		if rating, ok := ratingValue(event.RatingTo); ok && rating <= value {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterSpan describes the time between the first and last event of a cluster
+func clusterSpan(cluster []models.Stock) string {
+	days := int(cluster[len(cluster)-1].Time.Sub(cluster[0].Time).Hours() / 24)
+	if days <= 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
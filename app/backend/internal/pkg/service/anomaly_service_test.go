@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"truora-backend/internal/pkg/models"
+)
+
+func TestClusterEvents(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := func(days ...int) []models.Stock {
+		stocks := make([]models.Stock, 0, len(days))
+		for _, day := range days {
+			stocks = append(stocks, models.Stock{Time: start.AddDate(0, 0, day)})
+		}
+		return stocks
+	}
+
+	tests := []struct {
+		name   string
+		events []models.Stock
+		want   [][]int // day offsets of each cluster
+	}{
+		{name: "no events"},
+		{name: "single event", events: events(0), want: [][]int{{0}}},
+		{
+			name:   "window end is inclusive and the next cluster starts after it",
+			events: events(0, 2, 7, 8, 20),
+			want:   [][]int{{0, 2, 7}, {8}, {20}},
+		},
+		{
+			name:   "windows are measured from the cluster's first event",
+			events: events(0, 5, 10, 15),
+			want:   [][]int{{0, 5}, {10, 15}},
+		},
+		{
+			name:   "events at the same time stay together",
+			events: events(3, 3, 3),
+			want:   [][]int{{3, 3, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clusterEvents(tt.events, 7)
+			if len(got) != len(tt.want) {
+				t.Fatalf("clusters = %d, want %d", len(got), len(tt.want))
+			}
+			for i, cluster := range got {
+				if len(cluster) != len(tt.want[i]) {
+					t.Fatalf("cluster %d has %d events, want %d", i, len(cluster), len(tt.want[i]))
+				}
+				for j, event := range cluster {
+					if want := start.AddDate(0, 0, tt.want[i][j]); !event.Time.Equal(want) {
+						t.Errorf("cluster %d event %d at %v, want %v", i, j, event.Time, want)
+					}
+				}
+			}
+		})
+	}
+}
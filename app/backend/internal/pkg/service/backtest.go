@@ -0,0 +1,447 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/repository"
+)
+
+// ErrInvalidBacktestConfig is returned when a backtest configuration fails validation
+var ErrInvalidBacktestConfig = errors.New("invalid backtest config")
+
+// maxBacktestDates bounds the number of rebalance dates of one backtest
+const maxBacktestDates = 1000
+
+type BacktestService interface {
+	Run(ctx context.Context, config models.BacktestConfig) (*models.BacktestResult, error)
+}
+
+type backtestService struct {
+	repo    repository.StockRepository
+	prices  repository.PriceRepository
+	scorer  tickerScorer
+	profile string // default scoring profile
+}
+
+// backtestObservation is one ticker scored on one rebalance date
+type backtestObservation struct {
+	ticker  string
+	score   float64
+	returns map[int]float64 // forward % return by horizon, only where prices exist
+}
+
+// priceSeries is a ticker's daily prices in ascending date order
+type priceSeries []models.StockPrice
+
+// NewBacktestService creates a new backtest service
+func NewBacktestService(repo repository.StockRepository, prices repository.PriceRepository, config Config) BacktestService {
+	// Backtests only compare scores, so the bootstrap behind confidence intervals is skipped
+	config.BootstrapSamples = 0
+	return &backtestService{
+		repo:    repo,
+		prices:  prices,
+		scorer:  newTickerScorer(config),
+		profile: config.Profile.Name,
+	}
+}
+
+// Run replays analyst events as of each rebalance date between Start and End, scores every
+// covered ticker with the chosen profile, selects picks with the chosen strategy and measures
+// their forward returns from stored daily prices
+func (s *backtestService) Run(ctx context.Context, config models.BacktestConfig) (*models.BacktestResult, error) {
+	config, profile, err := s.normalizeConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	maxHorizon := 0
+	for _, horizon := range config.HorizonDays {
+		if horizon > maxHorizon {
+			maxHorizon = horizon
+		}
+	}
+	priceFrom := config.Start.AddDate(0, 0, -10)
+	priceTo := config.End.AddDate(0, 0, maxHorizon+10)
+
+	// Load each ticker's events up to the end date along with its price history
+	eventsByTicker := make(map[string][]models.Stock)
+	pricesByTicker := make(map[string]priceSeries)
+	err = s.repo.StreamTickerEvents(ctx, nil, func(ticker string, events []models.Stock) error {
+		var kept []models.Stock
+		for _, event := range events {
+			if !event.Time.After(config.End) {
+				kept = append(kept, event)
+			}
+		}
+		if len(kept) == 0 {
+			return nil
+		}
+
+		series, err := s.loadPrices(ticker, priceFrom, priceTo)
+		if err != nil {
+			return err
+		}
+		if len(series) == 0 {
+			return nil
+		}
+
+		eventsByTicker[ticker] = kept
+		pricesByTicker[ticker] = series
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load backtest data: %w", err)
+	}
+	if len(eventsByTicker) == 0 {
+		return nil, fmt.Errorf("%w: no tickers have both analyst events and prices", ErrInvalidBacktestConfig)
+	}
+
+	var benchmark priceSeries
+	if config.Benchmark != "" {
+		if benchmark, err = s.loadPrices(config.Benchmark, priceFrom, priceTo); err != nil {
+			return nil, err
+		}
+		if len(benchmark) == 0 {
+			return nil, fmt.Errorf("%w: no prices for benchmark %s", ErrInvalidBacktestConfig, config.Benchmark)
+		}
+	}
+
+	log.Printf("Backtesting %s/%s on %d tickers from %s to %s", config.Strategy, profile.Name,
+		len(eventsByTicker), config.Start.Format("2006-01-02"), config.End.Format("2006-01-02"))
+
+	result := &models.BacktestResult{
+		Config:      config,
+		Tickers:     len(eventsByTicker),
+		GeneratedAt: time.Now(),
+	}
+	horizons := make([]models.BacktestHorizonResult, len(config.HorizonDays))
+	pooled := make([][]backtestObservation, len(config.HorizonDays))
+	hits := make([]int, len(config.HorizonDays))
+	beats := make([]int, len(config.HorizonDays))
+	for i, horizon := range config.HorizonDays {
+		horizons[i].HorizonDays = horizon
+	}
+
+	for date := config.Start; !date.After(config.End); date = date.AddDate(0, 0, config.StepDays) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result.Dates++
+
+		observations := s.scoreAsOf(date, config, profile, eventsByTicker, pricesByTicker)
+		picks := selectPicks(observations, config)
+
+		for i, horizon := range config.HorizonDays {
+			benchmarkReturn, ok := periodBenchmark(date, horizon, benchmark, observations)
+			if !ok {
+				continue
+			}
+
+			var pickReturns []float64
+			for _, pick := range picks {
+				forward, ok := pick.returns[horizon]
+				if !ok {
+					continue
+				}
+				pickReturns = append(pickReturns, forward)
+				if forward > 0 {
+					hits[i]++
+				}
+				if forward > benchmarkReturn {
+					beats[i]++
+				}
+			}
+			for _, observation := range observations {
+				if _, ok := observation.returns[horizon]; ok {
+					pooled[i] = append(pooled[i], observation)
+				}
+			}
+
+			if len(pickReturns) == 0 {
+				continue
+			}
+			horizons[i].Picks += len(pickReturns)
+			horizons[i].Periods = append(horizons[i].Periods, models.BacktestPeriodResult{
+				Date:            date,
+				Picks:           len(pickReturns),
+				AvgReturn:       round2(mean(pickReturns)),
+				BenchmarkReturn: round2(benchmarkReturn),
+			})
+		}
+	}
+
+	for i := range horizons {
+		summarizeHorizon(&horizons[i], hits[i], beats[i], pooled[i])
+	}
+	result.Horizons = horizons
+	return result, nil
+}
+
+// normalizeConfig applies defaults and validates a backtest configuration
+func (s *backtestService) normalizeConfig(config models.BacktestConfig) (models.BacktestConfig, ScoringProfile, error) {
+	invalid := func(format string, args ...interface{}) (models.BacktestConfig, ScoringProfile, error) {
+		return config, ScoringProfile{}, fmt.Errorf("%w: %s", ErrInvalidBacktestConfig, fmt.Sprintf(format, args...))
+	}
+
+	if config.Start.IsZero() || config.End.IsZero() {
+		return invalid("start and end are required")
+	}
+	config.Start = truncateToDay(config.Start)
+	config.End = truncateToDay(config.End)
+	if !config.End.After(config.Start) {
+		return invalid("end must be after start")
+	}
+
+	if config.StepDays == 0 {
+		config.StepDays = 7
+	}
+	if config.StepDays < 1 {
+		return invalid("step_days must be positive")
+	}
+	if int(config.End.Sub(config.Start).Hours()/24)/config.StepDays+1 > maxBacktestDates {
+		return invalid("too many rebalance dates, at most %d are allowed", maxBacktestDates)
+	}
+
+	if len(config.HorizonDays) == 0 {
+		config.HorizonDays = []int{30, 90}
+	}
+	for _, horizon := range config.HorizonDays {
+		if horizon < 1 || horizon > 730 {
+			return invalid("horizon_days must be between 1 and 730")
+		}
+	}
+
+	if config.Strategy == "" {
+		config.Strategy = models.StrategyTopN
+	}
+	switch config.Strategy {
+	case models.StrategyTopN:
+		if config.TopN == 0 {
+			config.TopN = 10
+		}
+		if config.TopN < 1 {
+			return invalid("top_n must be positive")
+		}
+	case models.StrategyThreshold:
+		if config.MinScore == nil {
+			minScore := 60.0
+			config.MinScore = &minScore
+		}
+		if *config.MinScore < 0 || *config.MinScore > 100 {
+			return invalid("min_score must be between 0 and 100")
+		}
+	default:
+		return invalid("unknown strategy %q, expected %s or %s", config.Strategy, models.StrategyTopN, models.StrategyThreshold)
+	}
+
+	if config.Profile == "" {
+		config.Profile = s.profile
+	}
+	profile, ok := LookupScoringProfile(config.Profile)
+	if !ok {
+		return invalid("unknown profile %q, expected one of %s", config.Profile, strings.Join(ScoringProfileNames(), ", "))
+	}
+
+	if config.LookbackDays < 0 {
+		return invalid("lookback_days must not be negative")
+	}
+	config.Benchmark = strings.ToUpper(strings.TrimSpace(config.Benchmark))
+
+	return config, profile, nil
+}
+
+// loadPrices loads a ticker's daily prices between from and to in ascending date order
+func (s *backtestService) loadPrices(ticker string, from, to time.Time) (priceSeries, error) {
+	return loadPriceSeries(s.prices, ticker, from, to)
+}
+
+// loadPriceSeries loads a ticker's daily prices between from and to in ascending date order
+func loadPriceSeries(repo repository.PriceRepository, ticker string, from, to time.Time) (priceSeries, error) {
+	limit := int(to.Sub(from).Hours()/24) + 1
+	prices, err := repo.GetPrices(ticker, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Date.Before(prices[j].Date)
+	})
+	return priceSeries(prices), nil
+}
+
+// scoreAsOf scores every ticker using only the events known on date
+func (s *backtestService) scoreAsOf(date time.Time, config models.BacktestConfig, profile ScoringProfile,
+	eventsByTicker map[string][]models.Stock, pricesByTicker map[string]priceSeries) []backtestObservation {
+	// Events published during the day count towards that day's decision
+	cutoff := date.AddDate(0, 0, 1)
+	var lookbackStart time.Time
+	if config.LookbackDays > 0 {
+		lookbackStart = cutoff.AddDate(0, 0, -config.LookbackDays)
+	}
+
+	var observations []backtestObservation
+	for ticker, events := range eventsByTicker {
+		var known []models.Stock
+		for _, event := range events {
+			if event.Time.Before(cutoff) && !event.Time.Before(lookbackStart) {
+				known = append(known, event)
+			}
+		}
+		if len(known) == 0 {
+			continue
+		}
+
+		series := pricesByTicker[ticker]
+		startPrice, ok := series.closeOn(date)
+		if !ok {
+			continue
+		}
+
+		// Current credibility weights would leak future outcomes, so every brokerage weighs the same
+		inputs := scoringInputs{Profile: profile, AsOf: cutoff}
+		recommendation := s.scorer.evaluateTicker(ticker, known, startPrice.Close, inputs, models.TimeHorizonMedium)
+		observation := backtestObservation{
+			ticker:  ticker,
+			score:   recommendation.RecommendationScore,
+			returns: make(map[int]float64),
+		}
+		for _, horizon := range config.HorizonDays {
+			if forward, ok := series.forwardReturn(date, horizon); ok {
+				observation.returns[horizon] = forward
+			}
+		}
+		observations = append(observations, observation)
+	}
+	return observations
+}
+
+// selectPicks applies the strategy to the observations of one date
+func selectPicks(observations []backtestObservation, config models.BacktestConfig) []backtestObservation {
+	sorted := make([]backtestObservation, len(observations))
+	copy(sorted, observations)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].score != sorted[j].score {
+			return sorted[i].score > sorted[j].score
+		}
+		return sorted[i].ticker < sorted[j].ticker
+	})
+
+	switch config.Strategy {
+	case models.StrategyThreshold:
+		n := sort.Search(len(sorted), func(i int) bool { return sorted[i].score < *config.MinScore })
+		return sorted[:n]
+	default:
+		if len(sorted) > config.TopN {
+			return sorted[:config.TopN]
+		}
+		return sorted
+	}
+}
+
+// periodBenchmark returns the benchmark's forward return from date, or the equal-weighted
+// return of every scored ticker when no benchmark ticker is configured
+func periodBenchmark(date time.Time, horizon int, benchmark priceSeries, observations []backtestObservation) (float64, bool) {
+	if benchmark != nil {
+		return benchmark.forwardReturn(date, horizon)
+	}
+
+	var returns []float64
+	for _, observation := range observations {
+		if forward, ok := observation.returns[horizon]; ok {
+			returns = append(returns, forward)
+		}
+	}
+	if len(returns) == 0 {
+		return 0, false
+	}
+	return mean(returns), true
+}
+
+// summarizeHorizon fills in the aggregate metrics of a horizon
+func summarizeHorizon(result *models.BacktestHorizonResult, hits, beats int, pooled []backtestObservation) {
+	if result.Picks > 0 {
+		result.HitRate = round2(float64(hits) / float64(result.Picks) * 100)
+		result.BeatBenchmarkRate = round2(float64(beats) / float64(result.Picks) * 100)
+	}
+
+	// Periods are weighted equally, like a portfolio rebalanced on every date
+	if len(result.Periods) > 0 {
+		var pickReturns, benchmarkReturns []float64
+		for _, period := range result.Periods {
+			pickReturns = append(pickReturns, period.AvgReturn)
+			benchmarkReturns = append(benchmarkReturns, period.BenchmarkReturn)
+		}
+		result.AvgReturn = round2(mean(pickReturns))
+		result.BenchmarkReturn = round2(mean(benchmarkReturns))
+		result.ExcessReturn = round2(result.AvgReturn - result.BenchmarkReturn)
+	}
+
+	sort.Slice(pooled, func(i, j int) bool {
+		return pooled[i].score < pooled[j].score
+	})
+	buckets := 10
+	if len(pooled) < buckets {
+		buckets = len(pooled)
+	}
+	for decile := 0; decile < buckets; decile++ {
+		start := decile * len(pooled) / buckets
+		end := (decile + 1) * len(pooled) / buckets
+
+		var returns []float64
+		for _, observation := range pooled[start:end] {
+			returns = append(returns, observation.returns[result.HorizonDays])
+		}
+		result.Deciles = append(result.Deciles, models.BacktestDecileResult{
+			Decile:    decile + 1,
+			MinScore:  round2(pooled[start].score),
+			MaxScore:  round2(pooled[end-1].score),
+			Count:     end - start,
+			AvgReturn: round2(mean(returns)),
+		})
+	}
+}
+
+// closeOn returns the last price on or before date
+func (p priceSeries) closeOn(date time.Time) (models.StockPrice, bool) {
+	i := sort.Search(len(p), func(i int) bool { return p[i].Date.After(date) })
+	if i == 0 {
+		return models.StockPrice{}, false
+	}
+	return p[i-1], true
+}
+
+// forwardReturn returns the % change of the close from date to date+horizon days. It is not
+// available when the series ends before the horizon or has no newer price at its end.
+func (p priceSeries) forwardReturn(date time.Time, horizon int) (float64, bool) {
+	end := date.AddDate(0, 0, horizon)
+	if len(p) == 0 || p[len(p)-1].Date.Before(end) {
+		return 0, false
+	}
+
+	start, ok := p.closeOn(date)
+	if !ok || start.Close <= 0 {
+		return 0, false
+	}
+	finish, ok := p.closeOn(end)
+	if !ok || !finish.Date.After(start.Date) {
+		return 0, false
+	}
+	return (finish.Close - start.Close) / start.Close * 100, true
+}
+
+// truncateToDay drops the time of day from t, in UTC
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// round2 rounds a value to two decimal places
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
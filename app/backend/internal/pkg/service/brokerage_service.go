@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/repository"
+)
+
+// ErrAmbiguousBrokerage is returned when a brokerage id is the slug of several brokerages
+var ErrAmbiguousBrokerage = errors.New("brokerage id matches several brokerages")
+
+// credibilityPriorCalls is the number of neutral calls blended into every track record, so
+// brokerages with few evaluated calls stay close to the neutral credibility of 1
+const credibilityPriorCalls = 10
+
+type BrokerageService interface {
+	EvaluateTrackRecords(ctx context.Context) (int, error)
+	GetLeaderboard(minCalls, limit int) ([]models.BrokerageScore, error)
+	GetDirectory() ([]models.BrokerageSummary, error)
+	GetProfile(id string, recentLimit int) (*models.BrokerageProfile, error)
+}
+
+type brokerageService struct {
+	repo       repository.StockRepository
+	prices     repository.PriceRepository
+	brokerages repository.BrokerageRepository
+	config     Config
+}
+
+// trackRecord accumulates the evaluated calls of one brokerage
+type trackRecord struct {
+	name           string
+	calls          int
+	hits           int
+	priceCalls     int
+	consensusCalls int
+	returns        []float64
+}
+
+// NewBrokerageService creates a new brokerage service
+func NewBrokerageService(repo repository.StockRepository, prices repository.PriceRepository,
+	brokerages repository.BrokerageRepository, config Config) BrokerageService {
+	return &brokerageService{
+		repo:       repo,
+		prices:     prices,
+		brokerages: brokerages,
+		config:     config,
+	}
+}
+
+// EvaluateTrackRecords grades every directional call made at least TrackRecordHorizonDays ago.
+// A call is checked against the ticker's price move over the horizon when stored prices cover it,
+// and otherwise against how the other brokerages' consensus rating moved over the same period.
+// It stores one credibility score per brokerage and returns the number of brokerages scored.
+func (s *brokerageService) EvaluateTrackRecords(ctx context.Context) (int, error) {
+	horizon := s.config.TrackRecordHorizonDays
+	now := time.Now()
+	matured := now.AddDate(0, 0, -horizon)
+
+	records := make(map[string]*trackRecord)
+	err := s.repo.StreamTickerEvents(ctx, nil, func(ticker string, events []models.Stock) error {
+		var series priceSeries
+		loaded := false
+
+		for _, event := range events {
+			if event.Time.After(matured) {
+				continue
+			}
+			direction := callDirection(event)
+			if direction == 0 {
+				continue
+			}
+
+			if !loaded {
+				// Events are newest first, so the last one bounds the prices needed
+				oldest := truncateToDay(events[len(events)-1].Time)
+				var err error
+				if series, err = loadPriceSeries(s.prices, ticker, oldest.AddDate(0, 0, -10), now); err != nil {
+					return err
+				}
+				loaded = true
+			}
+
+			key := strings.ToLower(strings.TrimSpace(event.Brokerage))
+			record, ok := records[key]
+			if !ok {
+				record = &trackRecord{name: strings.TrimSpace(event.Brokerage)}
+				records[key] = record
+			}
+
+			if forward, ok := series.forwardReturn(truncateToDay(event.Time), horizon); ok {
+				record.calls++
+				record.priceCalls++
+				record.returns = append(record.returns, forward*float64(direction))
+				if forward*float64(direction) > 0 {
+					record.hits++
+				}
+				continue
+			}
+
+			if delta, ok := consensusShift(events, event, horizon); ok {
+				record.calls++
+				record.consensusCalls++
+				if delta*float64(direction) > 0 {
+					record.hits++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to evaluate brokerage track records: %w", err)
+	}
+
+	scores := make([]models.BrokerageScore, 0, len(records))
+	for _, record := range records {
+		if record.calls == 0 {
+			continue
+		}
+		scores = append(scores, record.score(now))
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].Brokerage < scores[j].Brokerage
+	})
+
+	if err := s.brokerages.ReplaceScores(ctx, scores); err != nil {
+		return 0, err
+	}
+	log.Printf("Evaluated track records of %d brokerages", len(scores))
+	return len(scores), nil
+}
+
+// GetLeaderboard retrieves the most credible brokerages with at least minCalls evaluated calls
+func (s *brokerageService) GetLeaderboard(minCalls, limit int) ([]models.BrokerageScore, error) {
+	return s.brokerages.GetLeaderboard(minCalls, limit)
+}
+
+// GetDirectory lists every brokerage with analyst events, most active first. Brokerages whose
+// names share a slug get their full name as id instead, so every id names one brokerage.
+func (s *brokerageService) GetDirectory() ([]models.BrokerageSummary, error) {
+	summaries, err := s.brokerages.GetDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	slugCounts := make(map[string]int)
+	for _, summary := range summaries {
+		slugCounts[brokerageSlug(summary.Name)]++
+	}
+	for i := range summaries {
+		summaries[i].ID = brokerageSlug(summaries[i].Name)
+		if slugCounts[summaries[i].ID] > 1 {
+			summaries[i].ID = summaries[i].Name
+		}
+	}
+	return summaries, nil
+}
+
+// GetProfile returns the profile of the brokerage with a directory id and its recentLimit latest
+// actions, or nil when no brokerage has that id. The id is the brokerage's slug or its exact name;
+// a slug shared by several brokerages returns ErrAmbiguousBrokerage.
+func (s *brokerageService) GetProfile(id string, recentLimit int) (*models.BrokerageProfile, error) {
+	matches, err := s.brokerages.FindBrokerages(id, brokerageSlug(id))
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &models.BrokerageProfile{}
+	for _, match := range matches {
+		if match.Name == id {
+			profile.BrokerageSummary = match
+			break
+		}
+	}
+	if profile.Name == "" {
+		switch len(matches) {
+		case 0:
+			return nil, nil
+		case 1:
+			profile.BrokerageSummary = matches[0]
+		default:
+			names := make([]string, len(matches))
+			for i, match := range matches {
+				names[i] = match.Name
+			}
+			return nil, fmt.Errorf("%w: %s", ErrAmbiguousBrokerage, strings.Join(names, ", "))
+		}
+	}
+	profile.ID = brokerageSlug(profile.Name)
+	if len(matches) > 1 {
+		profile.ID = profile.Name
+	}
+
+	activity, err := s.brokerages.GetActivity(profile.Name)
+	if err != nil {
+		return nil, err
+	}
+	profile.BrokerageActivity = *activity
+	if activity.DowngradeCount > 0 {
+		ratio := math.Round(float64(activity.UpgradeCount)/float64(activity.DowngradeCount)*100) / 100
+		profile.UpgradeRatio = &ratio
+	}
+
+	if profile.TrackRecord, err = s.brokerages.GetScore(profile.Name); err != nil {
+		return nil, err
+	}
+	if profile.CoveredTickers, err = s.brokerages.GetCoverage(profile.Name); err != nil {
+		return nil, err
+	}
+	profile.RecentActions, _, err = s.repo.GetStocks(models.StockFilter{Brokerage: profile.Name, Limit: recentLimit}, false)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// brokerageSlug derives a brokerage's directory id from its name: lowercase letters and digits
+// with every other run of characters turned into a single dash
+func brokerageSlug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// score turns a track record into a stored credibility score. The hit rate is shrunk towards
+// 50% by credibilityPriorCalls neutral calls and mapped onto a weight between 0.5 and 1.5.
+func (r *trackRecord) score(evaluatedAt time.Time) models.BrokerageScore {
+	smoothed := (float64(r.hits) + 0.5*credibilityPriorCalls) / float64(r.calls+credibilityPriorCalls)
+
+	score := models.BrokerageScore{
+		Brokerage:      r.name,
+		Calls:          r.calls,
+		Hits:           r.hits,
+		HitRate:        round2(float64(r.hits) / float64(r.calls) * 100),
+		PriceCalls:     r.priceCalls,
+		ConsensusCalls: r.consensusCalls,
+		Credibility:    math.Round((0.5+smoothed)*10000) / 10000,
+		EvaluatedAt:    evaluatedAt,
+	}
+	if len(r.returns) > 0 {
+		avgReturn := clampPercent(mean(r.returns))
+		score.AvgReturn = &avgReturn
+	}
+	return score
+}
+
+// callDirection tells whether an event is a bullish (1) or bearish (-1) call. Rating changes
+// decide first; unchanged ratings count by their level, so reiterated buys and sells are graded too.
+func callDirection(stock models.Stock) int {
+	if direction := ratingDirection(stock); direction != 0 {
+		return direction
+	}
+
+	value, ok := ratingValue(stock.RatingTo)
+	if !ok {
+		return 0
+	}
+	if value >= 4 {
+		return 1
+	} else if value <= 2 {
+		return -1
+	}
+	return 0
+}
+
+// consensusShift returns how far the other brokerages' mean rating moved between the call and
+// horizon days later. It is not available when either side has no ratings or nothing moved.
+func consensusShift(events []models.Stock, call models.Stock, horizon int) (float64, bool) {
+	end := call.Time.AddDate(0, 0, horizon)
+	brokerage := strings.ToLower(strings.TrimSpace(call.Brokerage))
+
+	var before, after []models.Stock
+	for _, event := range events {
+		if strings.ToLower(strings.TrimSpace(event.Brokerage)) == brokerage {
+			continue
+		}
+		if !event.Time.After(call.Time) {
+			before = append(before, event)
+		}
+		if !event.Time.After(end) {
+			after = append(after, event)
+		}
+	}
+
+	start, ok := meanRating(before)
+	if !ok {
+		return 0, false
+	}
+	finish, ok := meanRating(after)
+	if !ok || finish == start {
+		return 0, false
+	}
+	return finish - start, true
+}
+
+// meanRating averages each brokerage's latest rating on the 1-5 scale
+func meanRating(stocks []models.Stock) (float64, bool) {
+	var values []float64
+	for _, stock := range latestByBrokerage(stocks) {
+		if value, ok := ratingValue(stock.RatingTo); ok {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return 0, false
+	}
+	return mean(values), true
+}
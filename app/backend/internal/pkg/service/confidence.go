@@ -0,0 +1,60 @@
+package service
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"truora-backend/internal/pkg/models"
+)
+
+// coverageMargin is the half-width of the interval around a score backed by a single brokerage.
+// It shrinks with the square root of the number of brokerages covering the ticker.
+const coverageMargin = 30.0
+
+// scoreInterval is a 90% interval around a recommendation score and the confidence it implies
+type scoreInterval struct {
+	Lower      float64
+	Upper      float64
+	Confidence float64 // 100 minus the interval width, 0-100
+}
+
+// estimateScoreInterval bounds a score by rescoring BootstrapSamples resamples of the ticker's
+// events, drawn with replacement, and taking their 5th and 95th percentiles. Resampling one or two
+// events barely moves the score, so the interval is widened to at least a coverage margin that
+// shrinks as more brokerages cover the ticker. Resamples are seeded by ticker and horizon so
+// repeated runs over the same events agree.
+func (s *stockService) estimateScoreInterval(ticker string, stocks []models.Stock, referencePrice float64,
+	inputs scoringInputs, horizon string, score float64) scoreInterval {
+	lower, upper := score, score
+
+	if samples := s.config.BootstrapSamples; samples > 0 && len(stocks) > 1 {
+		seed := fnv.New64a()
+		seed.Write([]byte(ticker + "/" + horizon))
+		rng := rand.New(rand.NewSource(int64(seed.Sum64())))
+
+		scores := make([]float64, samples)
+		resample := make([]models.Stock, len(stocks))
+		for i := range scores {
+			for j := range resample {
+				resample[j] = stocks[rng.Intn(len(stocks))]
+			}
+			estimate := calculateTargetEstimate(resample, referencePrice)
+			scores[i] = s.calculateRecommendationScore(resample, estimate, inputs, horizon)
+		}
+		sort.Float64s(scores)
+		lower = math.Min(lower, scores[int(0.05*float64(samples-1))])
+		upper = math.Max(upper, scores[int(math.Ceil(0.95*float64(samples-1)))])
+	}
+
+	margin := coverageMargin / math.Sqrt(float64(len(distinctBrokerages(stocks))))
+	lower = math.Min(lower, score-margin)
+	upper = math.Max(upper, score+margin)
+
+	// Confidence uses the unclamped width, so scores pinned at 0 or 100 are not favoured
+	return scoreInterval{
+		Lower:      round2(math.Max(0, lower)),
+		Upper:      round2(math.Min(100, upper)),
+		Confidence: round2(math.Max(0, 100-(upper-lower))),
+	}
+}
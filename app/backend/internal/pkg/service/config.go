@@ -0,0 +1,181 @@
+package service
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"truora-backend/internal/pkg/models"
+)
+
+// Config holds tunable settings for the recommendation engine
+type Config struct {
+	// RiskLowThreshold is the risk score below which a ticker is "low" risk
+	RiskLowThreshold float64
+	// RiskHighThreshold is the risk score at or above which a ticker is "high" risk
+	RiskHighThreshold float64
+	// Workers is the number of goroutines scoring tickers concurrently
+	Workers int
+	// BatchSize is the number of recommendations written per transaction
+	BatchSize int
+	// Profile weights the signals of the recommendation score
+	Profile ScoringProfile
+	// UseCredibility weights each analyst event by its brokerage's track-record credibility
+	UseCredibility bool
+	// TrackRecordHorizonDays is how long after a call its outcome is measured
+	TrackRecordHorizonDays int
+	// AnomalyLookbackDays is how far back the anomaly detector scans a ticker's events
+	AnomalyLookbackDays int
+	// AnomalyBurstDays is the window within which clustered downgrades or initiations form a burst
+	AnomalyBurstDays int
+	// AnomalyBurstBrokerages is the number of distinct brokerages that make a downgrade burst
+	AnomalyBurstBrokerages int
+	// AnomalyTargetCut is the target price cut, in percent, that counts as large
+	AnomalyTargetCut float64
+	// AnomalyReversalDays is the window within which a brokerage reversing its call is flagged
+	AnomalyReversalDays int
+	// BootstrapSamples is the number of event resamples behind each score's confidence interval;
+	// 0 leaves only the coverage-based interval
+	BootstrapSamples int
+	// GradeCutoffs are the minimum percentiles of grades A to D, anything lower is E; nil disables grading
+	GradeCutoffs []float64
+}
+
+// DefaultConfig returns the default engine configuration
+func DefaultConfig() Config {
+	return Config{
+		RiskLowThreshold:       33,
+		RiskHighThreshold:      66,
+		Workers:                runtime.NumCPU(),
+		BatchSize:              100,
+		Profile:                scoringProfiles["default"],
+		UseCredibility:         true,
+		TrackRecordHorizonDays: 90,
+		AnomalyLookbackDays:    30,
+		AnomalyBurstDays:       5,
+		AnomalyBurstBrokerages: 3,
+		AnomalyTargetCut:       20,
+		AnomalyReversalDays:    30,
+		BootstrapSamples:       200,
+		GradeCutoffs:           []float64{80, 60, 40, 20},
+	}
+}
+
+// ConfigFromEnv returns the default configuration overridden by environment variables
+func ConfigFromEnv() Config {
+	config := DefaultConfig()
+	config.RiskLowThreshold = getEnvFloat("RISK_LOW_THRESHOLD", config.RiskLowThreshold)
+	config.RiskHighThreshold = getEnvFloat("RISK_HIGH_THRESHOLD", config.RiskHighThreshold)
+	config.Workers = getEnvInt("RECOMMENDATION_WORKERS", config.Workers)
+	config.BatchSize = getEnvInt("RECOMMENDATION_BATCH_SIZE", config.BatchSize)
+	config.UseCredibility = getEnvBool("CREDIBILITY_WEIGHTS", config.UseCredibility)
+	config.TrackRecordHorizonDays = getEnvInt("TRACK_RECORD_HORIZON_DAYS", config.TrackRecordHorizonDays)
+	config.BootstrapSamples = getEnvInt("SCORE_BOOTSTRAP_SAMPLES", config.BootstrapSamples)
+	config.AnomalyLookbackDays = getEnvInt("ANOMALY_LOOKBACK_DAYS", config.AnomalyLookbackDays)
+	config.AnomalyBurstDays = getEnvInt("ANOMALY_BURST_DAYS", config.AnomalyBurstDays)
+	config.AnomalyBurstBrokerages = getEnvInt("ANOMALY_BURST_BROKERAGES", config.AnomalyBurstBrokerages)
+	config.AnomalyTargetCut = getEnvFloat("ANOMALY_TARGET_CUT", config.AnomalyTargetCut)
+	config.AnomalyReversalDays = getEnvInt("ANOMALY_REVERSAL_DAYS", config.AnomalyReversalDays)
+
+	if config.RiskLowThreshold > config.RiskHighThreshold {
+		log.Printf("RISK_LOW_THRESHOLD %.2f exceeds RISK_HIGH_THRESHOLD %.2f, using defaults",
+			config.RiskLowThreshold, config.RiskHighThreshold)
+		defaults := DefaultConfig()
+		config.RiskLowThreshold = defaults.RiskLowThreshold
+		config.RiskHighThreshold = defaults.RiskHighThreshold
+	}
+	if value := os.Getenv("GRADE_PERCENTILES"); value != "" {
+		if cutoffs, ok := parseGradeCutoffs(value); ok {
+			config.GradeCutoffs = cutoffs
+		} else {
+			log.Printf("Invalid GRADE_PERCENTILES %s, using defaults", value)
+		}
+	}
+	if name := os.Getenv("SCORING_PROFILE"); name != "" {
+		if profile, ok := LookupScoringProfile(name); ok {
+			config.Profile = profile
+		} else {
+			log.Printf("Unknown SCORING_PROFILE %s, using %s", name, config.Profile.Name)
+		}
+	}
+
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.BatchSize < 1 {
+		config.BatchSize = 1
+	}
+	if config.BootstrapSamples < 0 {
+		config.BootstrapSamples = 0
+	}
+	if config.TrackRecordHorizonDays < 1 {
+		config.TrackRecordHorizonDays = DefaultConfig().TrackRecordHorizonDays
+	}
+	if config.AnomalyLookbackDays < 1 || config.AnomalyBurstDays < 1 || config.AnomalyBurstBrokerages < 2 ||
+		config.AnomalyTargetCut <= 0 || config.AnomalyReversalDays < 1 {
+		log.Printf("Invalid anomaly detector settings, using defaults")
+		defaults := DefaultConfig()
+		config.AnomalyLookbackDays = defaults.AnomalyLookbackDays
+		config.AnomalyBurstDays = defaults.AnomalyBurstDays
+		config.AnomalyBurstBrokerages = defaults.AnomalyBurstBrokerages
+		config.AnomalyTargetCut = defaults.AnomalyTargetCut
+		config.AnomalyReversalDays = defaults.AnomalyReversalDays
+	}
+	return config
+}
+
+// parseGradeCutoffs parses "off" or a comma-separated list of descending percentiles, one per
+// grade from A to D
+func parseGradeCutoffs(value string) ([]float64, bool) {
+	if strings.EqualFold(strings.TrimSpace(value), "off") {
+		return nil, true
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) != len(models.Grades)-1 {
+		return nil, false
+	}
+	cutoffs := make([]float64, 0, len(parts))
+	for i, part := range parts {
+		cutoff, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || cutoff < 0 || cutoff > 100 || (i > 0 && cutoff >= cutoffs[i-1]) {
+			return nil, false
+		}
+		cutoffs = append(cutoffs, cutoff)
+	}
+	return cutoffs, true
+}
+
+// getEnvFloat gets environment variable as float with fallback
+func getEnvFloat(key string, fallback float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+		log.Printf("Invalid number format for %s: %s, using fallback", key, value)
+	}
+	return fallback
+}
+
+// getEnvInt gets environment variable as integer with fallback
+func getEnvInt(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		log.Printf("Invalid integer format for %s: %s, using fallback", key, value)
+	}
+	return fallback
+}
+
+// getEnvBool gets environment variable as boolean with fallback
+func getEnvBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+		log.Printf("Invalid boolean format for %s: %s, using fallback", key, value)
+	}
+	return fallback
+}
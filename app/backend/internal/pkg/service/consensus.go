@@ -0,0 +1,110 @@
+package service
+
+import (
+	"math"
+	"truora-backend/internal/pkg/models"
+)
+
+// GetConsensus returns the current street consensus for a ticker, or nil when it has no coverage
+func (s *stockService) GetConsensus(ticker string) (*models.Consensus, error) {
+	stocks, err := s.repo.GetEventsByTicker(ticker)
+	if err != nil {
+		return nil, err
+	}
+	if len(stocks) == 0 {
+		return nil, nil
+	}
+
+	latestPrice, err := s.prices.GetLatestPrice(ticker)
+	if err != nil {
+		return nil, err
+	}
+	referencePrice := 0.0
+	if latestPrice != nil {
+		referencePrice = latestPrice.Close
+	}
+
+	consensus := s.buildConsensus(ticker, stocks, referencePrice)
+	if latestPrice != nil {
+		consensus.PriceDate = &latestPrice.Date
+	}
+	return &consensus, nil
+}
+
+// buildConsensus summarizes the latest rating and target of every brokerage covering the ticker.
+// A positive referencePrice adds the upside of the mean target over it.
+func (s *stockService) buildConsensus(ticker string, stocks []models.Stock, referencePrice float64) models.Consensus {
+	consensus := models.Consensus{Ticker: ticker}
+	latest := latestByBrokerage(stocks)
+	if len(latest) == 0 {
+		return consensus
+	}
+
+	// latestByBrokerage is ordered newest first
+	consensus.Company = latest[0].Company
+	consensus.LastChange = latest[0].Time
+	consensus.BrokerageCount = len(latest)
+
+	var ratings []float64
+	for _, stock := range latest {
+		value, ok := ratingValue(stock.RatingTo)
+		if !ok {
+			consensus.RatingDistribution.Unrated++
+			continue
+		}
+		ratings = append(ratings, value)
+
+		switch value {
+		case 5:
+			consensus.RatingDistribution.StrongBuy++
+		case 4:
+			consensus.RatingDistribution.Buy++
+		case 3:
+			consensus.RatingDistribution.Hold++
+		case 2:
+			consensus.RatingDistribution.Sell++
+		case 1:
+			consensus.RatingDistribution.StrongSell++
+		}
+	}
+
+	if len(ratings) > 0 {
+		consensus.ConsensusScore = math.Round(mean(ratings)*100) / 100
+		consensus.ConsensusRating = ratingLabel(consensus.ConsensusScore)
+	}
+
+	estimate := calculateTargetEstimate(stocks, referencePrice)
+	consensus.TargetCount = estimate.Count
+	consensus.TargetMean = round2(estimate.Mean)
+	consensus.TargetMedian = round2(estimate.Median)
+	consensus.TargetHigh = round2(estimate.High)
+	consensus.TargetLow = round2(estimate.Low)
+	consensus.ReferencePrice = optionalPrice(referencePrice)
+	consensus.Upside = estimate.Upside
+
+	return consensus
+}
+
+// optionalPrice returns nil for a missing (non-positive) price
+func optionalPrice(price float64) *float64 {
+	if price <= 0 {
+		return nil
+	}
+	return &price
+}
+
+// ratingLabel converts a 1-5 consensus score back to a rating name
+func ratingLabel(score float64) string {
+	switch {
+	case score >= 4.5:
+		return "Strong Buy"
+	case score >= 3.5:
+		return "Buy"
+	case score > 2.5:
+		return "Hold"
+	case score > 1.5:
+		return "Sell"
+	default:
+		return "Strong Sell"
+	}
+}
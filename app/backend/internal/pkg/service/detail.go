@@ -0,0 +1,91 @@
+package service
+
+import (
+	"math"
+	"time"
+	"truora-backend/internal/pkg/models"
+)
+
+// GetStockDetail returns a ticker's detail with its recommendation and history for a time horizon
+// over the last days, and the timeline events matching a filter. It returns nil when the ticker
+// has no analyst events.
+func (s *stockService) GetStockDetail(ticker, horizon string, days int, filter models.TimelineFilter) (*models.StockDetail, error) {
+	// The consensus only reads each brokerage's latest event
+	latest, err := s.repo.GetLatestEventsByBrokerage(ticker)
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) == 0 {
+		return nil, nil
+	}
+
+	latestPrice, err := s.prices.GetLatestPrice(ticker)
+	if err != nil {
+		return nil, err
+	}
+	referencePrice := 0.0
+	if latestPrice != nil {
+		referencePrice = latestPrice.Close
+	}
+
+	detail := &models.StockDetail{
+		Ticker:    ticker,
+		Company:   latest[0].Company,
+		Consensus: s.buildConsensus(ticker, latest, referencePrice),
+		Timeline:  []models.TimelineEvent{},
+	}
+	if latestPrice != nil {
+		detail.Consensus.PriceDate = &latestPrice.Date
+	}
+
+	classifications, err := s.sectors.GetSectorsByTicker([]string{ticker})
+	if err != nil {
+		return nil, err
+	}
+	if len(classifications) > 0 {
+		detail.Sector = classifications[0].Sector
+		detail.Industry = classifications[0].Industry
+	}
+
+	if detail.Recommendation, err = s.repo.GetRecommendationByTicker(ticker, horizon); err != nil {
+		return nil, err
+	}
+	if detail.RecommendationHistory, err = s.repo.GetRankHistory(ticker, horizon, time.Now().AddDate(0, 0, -days)); err != nil {
+		return nil, err
+	}
+
+	events, err := s.repo.GetTimeline(ticker, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		detail.Timeline = append(detail.Timeline, timelineEvent(event))
+	}
+	return detail, nil
+}
+
+// timelineEvent describes an analyst event by the rating and target change it made
+func timelineEvent(stock models.Stock) models.TimelineEvent {
+	event := models.TimelineEvent{
+		ID:         stock.ID,
+		Time:       stock.Time,
+		Brokerage:  stock.Brokerage,
+		Action:     stock.Action,
+		RatingFrom: stock.RatingFrom,
+		RatingTo:   stock.RatingTo,
+		TargetFrom: stock.TargetFromValue,
+		TargetTo:   stock.TargetToValue,
+	}
+
+	from, fromOK := ratingValue(stock.RatingFrom)
+	to, toOK := ratingValue(stock.RatingTo)
+	if fromOK && toOK {
+		change := to - from
+		event.RatingChange = &change
+	}
+	if stock.TargetFromValue != nil && stock.TargetToValue != nil && *stock.TargetFromValue > 0 {
+		change := math.Round((*stock.TargetToValue-*stock.TargetFromValue) / *stock.TargetFromValue * 10000) / 100
+		event.TargetChange = &change
+	}
+	return event
+}
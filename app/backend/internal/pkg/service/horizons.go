@@ -0,0 +1,71 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+)
+
+// horizonWeights adapts the scoring signals to an investment horizon
+type horizonWeights struct {
+	// HalfLifeDays halves an event's weight every so many days of age; 0 disables decay
+	HalfLifeDays float64
+	// UpgradeScale and RatingScale multiply the profile's upgrade and rating weights
+	UpgradeScale float64
+	RatingScale  float64
+	// LatestRatings counts only each brokerage's latest rating in the rating mix
+	LatestRatings bool
+	// TargetChangeWeight is the number of points per percent of average target change
+	TargetChangeWeight    float64
+	MaxTargetChangePoints float64
+	// InitiationWeight is the number of points per net bullish coverage initiation
+	InitiationWeight    float64
+	MaxInitiationPoints float64
+}
+
+// horizonWeightsByName holds the weights of each time horizon. Short-term scores follow recent
+// actions and target changes, long-term scores follow the standing consensus and initiations.
+var horizonWeightsByName = map[string]horizonWeights{
+	models.TimeHorizonShort: {
+		HalfLifeDays:          14,
+		UpgradeScale:          1.5,
+		RatingScale:           0.5,
+		TargetChangeWeight:    0.5,
+		MaxTargetChangePoints: 10,
+	},
+	models.TimeHorizonMedium: {
+		UpgradeScale: 1,
+		RatingScale:  1,
+	},
+	models.TimeHorizonLong: {
+		UpgradeScale:        0.5,
+		RatingScale:         1.5,
+		LatestRatings:       true,
+		InitiationWeight:    3,
+		MaxInitiationPoints: 12,
+	},
+}
+
+// IsTimeHorizon reports whether name is a known time horizon
+func IsTimeHorizon(name string) bool {
+	_, ok := horizonWeightsByName[name]
+	return ok
+}
+
+// decay returns the weight left to an event published at t when scoring as of asOf
+func (h horizonWeights) decay(asOf, t time.Time) float64 {
+	if h.HalfLifeDays <= 0 || asOf.IsZero() {
+		return 1
+	}
+	age := asOf.Sub(t).Hours() / 24
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, age/h.HalfLifeDays)
+}
+
+// isInitiation reports whether an action starts coverage of a ticker
+func isInitiation(action string) bool {
+	return strings.Contains(strings.ToLower(action), "initiat")
+}
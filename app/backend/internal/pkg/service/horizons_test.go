@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"truora-backend/internal/pkg/models"
+)
+
+func TestHorizonsScoreTheSameEventsDifferently(t *testing.T) {
+	asOf := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	// Standing buy coverage from months ago, then a fresh downgrade with a target cut
+	events := []models.Stock{
+		{Brokerage: "Goldman Sachs", Action: "initiated by", RatingTo: "Buy", Time: asOf.AddDate(0, 0, -120)},
+		{Brokerage: "Morgan Stanley", Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Buy", Time: asOf.AddDate(0, 0, -90)},
+		{Brokerage: "Goldman Sachs", Action: "downgraded by", RatingFrom: "Buy", RatingTo: "Neutral",
+			TargetFrom: "$120.00", TargetTo: "$100.00", Time: asOf.AddDate(0, 0, -2)},
+	}
+	profile, _ := LookupScoringProfile("default")
+	inputs := scoringInputs{Profile: profile, AsOf: asOf}
+
+	scores := make(map[string]float64)
+	for _, horizon := range []string{models.TimeHorizonShort, models.TimeHorizonMedium, models.TimeHorizonLong} {
+		weights := horizonWeightsByName[horizon]
+		scores[horizon] = combineScore(profile, weights, weighSignals(events, inputs, weights), 0)
+	}
+
+	// Short term follows the recent downgrade and target cut, medium term weighs every event
+	// alike, long term follows each brokerage's standing rating and the bullish initiation
+	short, medium, long := scores[models.TimeHorizonShort], scores[models.TimeHorizonMedium], scores[models.TimeHorizonLong]
+	if !(short < medium && medium < long) {
+		t.Fatalf("scores short %.2f, medium %.2f, long %.2f, want short < medium < long", short, medium, long)
+	}
+	if short >= 50 {
+		t.Errorf("short-term score %.2f, want below the neutral 50 after a fresh downgrade", short)
+	}
+}
+
+func TestHorizonDecay(t *testing.T) {
+	asOf := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	short := horizonWeightsByName[models.TimeHorizonShort]
+
+	tests := []struct {
+		name    string
+		weights horizonWeights
+		at      time.Time
+		want    float64
+	}{
+		{name: "no decay", weights: horizonWeightsByName[models.TimeHorizonMedium], at: asOf.AddDate(-1, 0, 0), want: 1},
+		{name: "fresh event", weights: short, at: asOf, want: 1},
+		{name: "one half-life", weights: short, at: asOf.AddDate(0, 0, -14), want: 0.5},
+		{name: "two half-lives", weights: short, at: asOf.AddDate(0, 0, -28), want: 0.25},
+		{name: "future event", weights: short, at: asOf.AddDate(0, 0, 3), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.weights.decay(asOf, tt.at); got != tt.want {
+				t.Errorf("decay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
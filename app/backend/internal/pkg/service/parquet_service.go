@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"truora-backend/internal/pkg/export"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/repository"
+)
+
+// ErrExportInProgress is returned when a Parquet export starts while another is still writing
+var ErrExportInProgress = errors.New("parquet export already in progress")
+
+// ParquetOptions controls a Parquet export
+type ParquetOptions struct {
+	// Dir is the directory the datasets are written under
+	Dir string
+	// PartitionByMonth writes analyst events by event month and runs by start month, one file per
+	// month; recommendations hold current state and are never partitioned
+	PartitionByMonth bool
+}
+
+type ParquetService interface {
+	Export(ctx context.Context, opts ParquetOptions) ([]export.ParquetFile, error)
+}
+
+type parquetService struct {
+	repo repository.StockRepository
+	mu   sync.Mutex
+}
+
+// NewParquetService creates a new Parquet export service
+func NewParquetService(repo repository.StockRepository) ParquetService {
+	return &parquetService{
+		repo: repo,
+	}
+}
+
+// Export writes analyst events, recommendations and recommendation runs to Parquet files under
+// the options' directory and returns the files written. The datasets are staged together and
+// replace their previous output, partitioned or not, only once all of them are written, so no
+// stale files are left beside them and a failed export keeps the previous one.
+func (s *parquetService) Export(ctx context.Context, opts ParquetOptions) ([]export.ParquetFile, error) {
+	if !s.mu.TryLock() {
+		return nil, ErrExportInProgress
+	}
+	defer s.mu.Unlock()
+
+	exp, err := export.NewParquetExport(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []export.ParquetFile
+	for _, write := range []func(context.Context, *export.ParquetExport, ParquetOptions) ([]export.ParquetFile, error){
+		s.exportStocks, s.exportRecommendations, s.exportRuns,
+	} {
+		written, err := write(ctx, exp, opts)
+		if err != nil {
+			exp.Abort()
+			return nil, err
+		}
+		files = append(files, written...)
+	}
+	if err := exp.Publish(); err != nil {
+		exp.Abort()
+		return nil, err
+	}
+	return files, nil
+}
+
+// exportStocks writes every analyst event, oldest first so each month's events are contiguous
+func (s *parquetService) exportStocks(ctx context.Context, exp *export.ParquetExport, opts ParquetOptions) ([]export.ParquetFile, error) {
+	writer := export.NewStockParquetWriter(exp, opts.PartitionByMonth)
+	filter := models.StockFilter{Sort: []models.SortKey{{Field: "time"}}}
+	err := s.repo.StreamStocks(ctx, filter, false, func(stock models.Stock) error {
+		return writer.Write(export.ParquetStockRow(stock), stock.Time)
+	})
+	if err != nil {
+		writer.Abort()
+		return nil, err
+	}
+	return writer.Close()
+}
+
+// exportRecommendations writes the current recommendation of every ticker and horizon
+func (s *parquetService) exportRecommendations(ctx context.Context, exp *export.ParquetExport, opts ParquetOptions) ([]export.ParquetFile, error) {
+	writer := export.NewRecommendationParquetWriter(exp, false)
+	err := s.repo.StreamRecommendations(ctx, models.RecommendationFilter{}, func(recommendation models.StockRecommendation) error {
+		return writer.Write(export.ParquetRecommendationRow(recommendation), recommendation.UpdatedAt)
+	})
+	if err != nil {
+		writer.Abort()
+		return nil, err
+	}
+	return writer.Close()
+}
+
+// exportRuns writes every recommendation run, oldest first
+func (s *parquetService) exportRuns(ctx context.Context, exp *export.ParquetExport, opts ParquetOptions) ([]export.ParquetFile, error) {
+	runs, err := s.repo.GetRecommendationRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := export.NewRecommendationRunParquetWriter(exp, opts.PartitionByMonth)
+	for _, run := range runs {
+		if err := writer.Write(export.ParquetRecommendationRunRow(run), run.StartedAt); err != nil {
+			writer.Abort()
+			return nil, err
+		}
+	}
+	return writer.Close()
+}
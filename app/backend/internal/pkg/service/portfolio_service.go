@@ -0,0 +1,330 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/repository"
+)
+
+// ErrInvalidPortfolioConfig is returned when a portfolio configuration fails validation
+var ErrInvalidPortfolioConfig = errors.New("invalid portfolio config")
+
+// unclassifiedSector labels positions whose ticker has no sector; they share one sector cap
+const unclassifiedSector = "Unclassified"
+
+// riskAversion is how strongly each risk tolerance penalizes a recommendation's risk score
+var riskAversion = map[string]float64{
+	models.RiskToleranceLow:    2,
+	models.RiskToleranceMedium: 1,
+	models.RiskToleranceHigh:   0,
+}
+
+// allowedRiskLevels are the recommendation risk levels each risk tolerance may hold
+var allowedRiskLevels = map[string][]string{
+	models.RiskToleranceLow:    {"low"},
+	models.RiskToleranceMedium: {"low", "medium"},
+	models.RiskToleranceHigh:   {"low", "medium", "high"},
+}
+
+type PortfolioService interface {
+	BuildPortfolio(config models.PortfolioConfig) (*models.Portfolio, error)
+	GetPortfolios(limit, offset int) ([]models.Portfolio, error)
+	GetPortfolio(id uint) (*models.Portfolio, error)
+}
+
+type portfolioService struct {
+	repo    repository.PortfolioRepository
+	stocks  repository.StockRepository
+	sectors repository.SectorRepository
+}
+
+// portfolioCandidate is a recommendation selected for a portfolio and its working weight
+type portfolioCandidate struct {
+	recommendation models.StockRecommendation
+	sector         string
+	weight         float64
+	capped         bool
+}
+
+// NewPortfolioService creates a new portfolio service
+func NewPortfolioService(repo repository.PortfolioRepository, stocks repository.StockRepository,
+	sectors repository.SectorRepository) PortfolioService {
+	return &portfolioService{
+		repo:    repo,
+		stocks:  stocks,
+		sectors: sectors,
+	}
+}
+
+// BuildPortfolio allocates a budget across the best current recommendations and saves the result.
+// Candidates must reach the minimum score and carry a risk level the tolerance allows. Weights
+// follow the score, discounted by risk for cautious tolerances, and no sector may exceed the
+// maximum sector weight; whatever the caps leave unallocated is kept as cash.
+func (s *portfolioService) BuildPortfolio(config models.PortfolioConfig) (*models.Portfolio, error) {
+	config, err := normalizePortfolioConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch a deep enough list that risk and sector limits can still fill every position
+	recommendations, err := s.stocks.GetTopRecommendations(models.RecommendationFilter{
+		TimeHorizon: config.TimeHorizon,
+		MinScore:    config.MinScore,
+		Limit:       config.MaxPositions * 10,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tickers := make([]string, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		tickers = append(tickers, recommendation.Ticker)
+	}
+	classifications, err := s.sectors.GetSectorsByTicker(tickers)
+	if err != nil {
+		return nil, err
+	}
+	sectorByTicker := make(map[string]string, len(classifications))
+	for _, classification := range classifications {
+		sectorByTicker[classification.Ticker] = classification.Sector
+	}
+
+	candidates := selectCandidates(recommendations, sectorByTicker, config)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no recommendations match the minimum score and risk tolerance", ErrInvalidPortfolioConfig)
+	}
+	allocateWeights(candidates, config)
+
+	portfolio := &models.Portfolio{
+		Name:            config.Name,
+		Budget:          config.Budget,
+		RiskTolerance:   config.RiskTolerance,
+		TimeHorizon:     config.TimeHorizon,
+		MaxPositions:    config.MaxPositions,
+		MaxSectorWeight: config.MaxSectorWeight,
+		MinScore:        config.MinScore,
+	}
+	for i, candidate := range candidates {
+		recommendation := candidate.recommendation
+		amount := round2(config.Budget * candidate.weight / 100)
+		position := models.PortfolioPosition{
+			Ticker:         recommendation.Ticker,
+			Company:        recommendation.Stock.Company,
+			Sector:         candidate.sector,
+			Weight:         round2(candidate.weight),
+			Amount:         amount,
+			ReferencePrice: recommendation.ReferencePrice,
+			Score:          recommendation.RecommendationScore,
+			RiskLevel:      recommendation.RiskLevel,
+			Rationale:      positionRationale(candidate, i+1, config),
+		}
+		if recommendation.ReferencePrice != nil && *recommendation.ReferencePrice > 0 {
+			shares := math.Round(amount / *recommendation.ReferencePrice * 10000) / 10000
+			position.Shares = &shares
+		}
+		portfolio.Invested += amount
+		portfolio.Positions = append(portfolio.Positions, position)
+	}
+	sortPositionsByWeight(portfolio.Positions)
+	portfolio.Invested = round2(portfolio.Invested)
+	portfolio.Cash = round2(config.Budget - portfolio.Invested)
+
+	if err := s.repo.CreatePortfolio(portfolio); err != nil {
+		return nil, err
+	}
+	return portfolio, nil
+}
+
+// GetPortfolios retrieves saved portfolios, newest first
+func (s *portfolioService) GetPortfolios(limit, offset int) ([]models.Portfolio, error) {
+	return s.repo.GetPortfolios(limit, offset)
+}
+
+// GetPortfolio retrieves a saved portfolio with its positions
+func (s *portfolioService) GetPortfolio(id uint) (*models.Portfolio, error) {
+	return s.repo.GetPortfolio(id)
+}
+
+// normalizePortfolioConfig applies defaults and validates a portfolio configuration
+func normalizePortfolioConfig(config models.PortfolioConfig) (models.PortfolioConfig, error) {
+	invalid := func(format string, args ...interface{}) (models.PortfolioConfig, error) {
+		return config, fmt.Errorf("%w: %s", ErrInvalidPortfolioConfig, fmt.Sprintf(format, args...))
+	}
+
+	if config.Budget <= 0 {
+		return invalid("budget must be positive")
+	}
+
+	if config.RiskTolerance == "" {
+		config.RiskTolerance = models.RiskToleranceMedium
+	}
+	if _, ok := riskAversion[config.RiskTolerance]; !ok {
+		return invalid("unknown risk_tolerance %q, expected low, medium or high", config.RiskTolerance)
+	}
+
+	if config.TimeHorizon == "" {
+		config.TimeHorizon = models.TimeHorizonMedium
+	}
+	if !IsTimeHorizon(config.TimeHorizon) {
+		return invalid("unknown time_horizon %q, expected short, medium or long", config.TimeHorizon)
+	}
+
+	if config.MaxPositions == 0 {
+		config.MaxPositions = 10
+	}
+	if config.MaxPositions < 1 || config.MaxPositions > 50 {
+		return invalid("max_positions must be between 1 and 50")
+	}
+
+	if config.MaxSectorWeight == 0 {
+		config.MaxSectorWeight = 40
+	}
+	if config.MaxSectorWeight < 0 || config.MaxSectorWeight > 100 {
+		return invalid("max_sector_weight must be between 0 and 100")
+	}
+
+	if config.MinScore < 0 || config.MinScore > 100 {
+		return invalid("min_score must be between 0 and 100")
+	}
+
+	config.Name = strings.TrimSpace(config.Name)
+	if config.Name == "" {
+		config.Name = fmt.Sprintf("%s risk portfolio %s", config.RiskTolerance, time.Now().Format("2006-01-02 15:04"))
+	}
+	return config, nil
+}
+
+// selectCandidates picks up to MaxPositions recommendations, best score first, skipping risk levels
+// the tolerance does not allow. No sector takes more positions than its weight cap can fund at an
+// equal split, so the cap does not leave most of the budget in cash.
+func selectCandidates(recommendations []models.StockRecommendation, sectorByTicker map[string]string,
+	config models.PortfolioConfig) []*portfolioCandidate {
+	maxPerSector := int(math.Floor(float64(config.MaxPositions) * config.MaxSectorWeight / 100))
+	if maxPerSector < 1 {
+		maxPerSector = 1
+	}
+
+	var candidates []*portfolioCandidate
+	perSector := make(map[string]int)
+	for _, recommendation := range recommendations {
+		if len(candidates) == config.MaxPositions {
+			break
+		}
+		if !containsString(allowedRiskLevels[config.RiskTolerance], recommendation.RiskLevel) {
+			continue
+		}
+
+		sector, ok := sectorByTicker[recommendation.Ticker]
+		if !ok {
+			sector = unclassifiedSector
+		}
+		if perSector[sector] >= maxPerSector {
+			continue
+		}
+		perSector[sector]++
+
+		candidates = append(candidates, &portfolioCandidate{recommendation: recommendation, sector: sector})
+	}
+	return candidates
+}
+
+// allocateWeights sets candidate weights in percent of the budget. Raw weights are scores
+// discounted by risk, normalized to 100%; sectors over the cap are scaled down to it and their
+// excess is spread over the uncapped candidates until no sector exceeds the cap.
+func allocateWeights(candidates []*portfolioCandidate, config models.PortfolioConfig) {
+	aversion := riskAversion[config.RiskTolerance]
+	total := 0.0
+	for _, candidate := range candidates {
+		candidate.weight = candidate.recommendation.RecommendationScore / (1 + aversion*candidate.recommendation.RiskScore/100)
+		total += candidate.weight
+	}
+	for _, candidate := range candidates {
+		if total > 0 {
+			candidate.weight = candidate.weight / total * 100
+		} else {
+			candidate.weight = 100 / float64(len(candidates))
+		}
+	}
+
+	for range candidates {
+		totals := make(map[string]float64)
+		for _, candidate := range candidates {
+			totals[candidate.sector] += candidate.weight
+		}
+
+		excess := 0.0
+		for sector, sectorTotal := range totals {
+			if sectorTotal <= config.MaxSectorWeight+1e-9 {
+				continue
+			}
+			excess += sectorTotal - config.MaxSectorWeight
+			for _, candidate := range candidates {
+				if candidate.sector == sector {
+					candidate.weight *= config.MaxSectorWeight / sectorTotal
+					candidate.capped = true
+				}
+			}
+		}
+		if excess == 0 {
+			return
+		}
+
+		free := 0.0
+		for _, candidate := range candidates {
+			if !candidate.capped {
+				free += candidate.weight
+			}
+		}
+		if free == 0 {
+			return // every sector is at its cap; the excess stays in cash
+		}
+		for _, candidate := range candidates {
+			if !candidate.capped {
+				candidate.weight += excess * candidate.weight / free
+			}
+		}
+	}
+}
+
+// positionRationale explains why a candidate is held and how its weight was set
+func positionRationale(candidate *portfolioCandidate, rank int, config models.PortfolioConfig) string {
+	recommendation := candidate.recommendation
+	reasons := []string{
+		fmt.Sprintf("#%d by %s-term score (%.1f) with %s risk", rank, config.TimeHorizon, recommendation.RecommendationScore, recommendation.RiskLevel),
+	}
+	if recommendation.ConsensusRating != "" {
+		reasons = append(reasons, fmt.Sprintf("consensus %s", recommendation.ConsensusRating))
+	}
+	if recommendation.Upside != nil {
+		reasons = append(reasons, fmt.Sprintf("%+.1f%% upside to the mean target", *recommendation.Upside))
+	}
+	if recommendation.Reason != "" {
+		reasons = append(reasons, recommendation.Reason)
+	}
+	if candidate.capped {
+		reasons = append(reasons, fmt.Sprintf("weight capped by the %.0f%% %s sector limit", config.MaxSectorWeight, candidate.sector))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortPositionsByWeight orders positions largest first
+func sortPositionsByWeight(positions []models.PortfolioPosition) {
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].Weight > positions[j].Weight
+	})
+}
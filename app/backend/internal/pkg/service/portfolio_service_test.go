@@ -0,0 +1,133 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"truora-backend/internal/pkg/models"
+)
+
+// candidate returns a portfolio candidate with a score, risk score and sector
+func candidate(ticker, sector string, score, riskScore float64) *portfolioCandidate {
+	return &portfolioCandidate{
+		recommendation: models.StockRecommendation{Ticker: ticker, RecommendationScore: score, RiskScore: riskScore},
+		sector:         sector,
+	}
+}
+
+func TestAllocateWeights(t *testing.T) {
+	tests := []struct {
+		name        string
+		candidates  []*portfolioCandidate
+		config      models.PortfolioConfig
+		wantWeights []float64
+		wantCapped  []bool
+	}{
+		{
+			name: "weights follow the score",
+			candidates: []*portfolioCandidate{
+				candidate("AAA", "Technology", 75, 0),
+				candidate("BBB", "Healthcare", 25, 0),
+			},
+			config:      models.PortfolioConfig{RiskTolerance: models.RiskToleranceHigh, MaxSectorWeight: 100},
+			wantWeights: []float64{75, 25},
+			wantCapped:  []bool{false, false},
+		},
+		{
+			name: "cautious tolerances discount risky candidates",
+			candidates: []*portfolioCandidate{
+				candidate("AAA", "Technology", 60, 0),
+				candidate("BBB", "Healthcare", 60, 50),
+			},
+			config:      models.PortfolioConfig{RiskTolerance: models.RiskToleranceLow, MaxSectorWeight: 100},
+			wantWeights: []float64{66.67, 33.33},
+			wantCapped:  []bool{false, false},
+		},
+		{
+			name: "zero scores split evenly",
+			candidates: []*portfolioCandidate{
+				candidate("AAA", "Technology", 0, 0),
+				candidate("BBB", "Healthcare", 0, 0),
+				candidate("CCC", "Energy", 0, 0),
+				candidate("DDD", "Utilities", 0, 0),
+			},
+			config:      models.PortfolioConfig{RiskTolerance: models.RiskToleranceHigh, MaxSectorWeight: 100},
+			wantWeights: []float64{25, 25, 25, 25},
+			wantCapped:  []bool{false, false, false, false},
+		},
+		{
+			name: "sector excess moves to other sectors",
+			candidates: []*portfolioCandidate{
+				candidate("AAA", "Technology", 40, 0),
+				candidate("BBB", "Technology", 40, 0),
+				candidate("CCC", "Healthcare", 10, 0),
+				candidate("DDD", "Energy", 10, 0),
+			},
+			config:      models.PortfolioConfig{RiskTolerance: models.RiskToleranceHigh, MaxSectorWeight: 50},
+			wantWeights: []float64{25, 25, 25, 25},
+			wantCapped:  []bool{true, true, false, false},
+		},
+		{
+			name: "excess no sector can take stays in cash",
+			candidates: []*portfolioCandidate{
+				candidate("AAA", "Technology", 50, 0),
+				candidate("BBB", "Technology", 50, 0),
+				candidate("CCC", "Healthcare", 50, 0),
+			},
+			config:      models.PortfolioConfig{RiskTolerance: models.RiskToleranceHigh, MaxSectorWeight: 40},
+			wantWeights: []float64{20, 20, 40},
+			wantCapped:  []bool{true, true, true},
+		},
+		{
+			name: "unclassified tickers share one sector cap",
+			candidates: []*portfolioCandidate{
+				candidate("AAA", unclassifiedSector, 50, 0),
+				candidate("BBB", unclassifiedSector, 50, 0),
+				candidate("CCC", unclassifiedSector, 50, 0),
+			},
+			config:      models.PortfolioConfig{RiskTolerance: models.RiskToleranceHigh, MaxSectorWeight: 40},
+			wantWeights: []float64{13.33, 13.33, 13.33},
+			wantCapped:  []bool{true, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocateWeights(tt.candidates, tt.config)
+			for i, c := range tt.candidates {
+				if math.Abs(c.weight-tt.wantWeights[i]) > 0.01 {
+					t.Errorf("%s weight = %.4f, want %.2f", c.recommendation.Ticker, c.weight, tt.wantWeights[i])
+				}
+				if c.capped != tt.wantCapped[i] {
+					t.Errorf("%s capped = %v, want %v", c.recommendation.Ticker, c.capped, tt.wantCapped[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSelectCandidatesLimitsUnclassifiedTickers(t *testing.T) {
+	recommendations := []models.StockRecommendation{
+		{Ticker: "AAA", RiskLevel: "low"},
+		{Ticker: "BBB", RiskLevel: "high"},
+		{Ticker: "CCC", RiskLevel: "low"},
+		{Ticker: "DDD", RiskLevel: "medium"},
+		{Ticker: "EEE", RiskLevel: "low"},
+	}
+	config := models.PortfolioConfig{RiskTolerance: models.RiskToleranceMedium, MaxPositions: 4, MaxSectorWeight: 50}
+
+	candidates := selectCandidates(recommendations, map[string]string{"EEE": "Energy"}, config)
+
+	var tickers []string
+	for _, c := range candidates {
+		tickers = append(tickers, c.recommendation.Ticker+"/"+c.sector)
+	}
+	want := []string{"AAA/Unclassified", "CCC/Unclassified", "EEE/Energy"}
+	if len(tickers) != len(want) {
+		t.Fatalf("candidates = %v, want %v", tickers, want)
+	}
+	for i := range want {
+		if tickers[i] != want[i] {
+			t.Fatalf("candidates = %v, want %v", tickers, want)
+		}
+	}
+}
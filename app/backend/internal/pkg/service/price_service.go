@@ -0,0 +1,189 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/repository"
+)
+
+type PriceService interface {
+	ImportCSV(path string) (int, error)
+	GetPrices(ticker string, from, to time.Time, limit int) ([]models.StockPrice, error)
+	GetLatestPrice(ticker string) (*models.StockPrice, error)
+}
+
+type priceService struct {
+	repo repository.PriceRepository
+}
+
+// priceDateLayouts are the date formats accepted in price CSV files
+var priceDateLayouts = []string{"2006-01-02", "01/02/2006", "2006/01/02", time.RFC3339}
+
+// NewPriceService creates a new price service
+func NewPriceService(repo repository.PriceRepository) PriceService {
+	return &priceService{repo: repo}
+}
+
+// ImportCSV imports daily prices from a CSV file, or from every .csv file in a directory.
+// Files need a header with date, open, high, low, close and volume columns. When there is no
+// ticker (or symbol) column, the ticker is taken from the file name, e.g. AAPL.csv.
+func (s *priceService) ImportCSV(path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open price data: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.csv"))
+		if err != nil {
+			return 0, fmt.Errorf("failed to list price files: %w", err)
+		}
+	}
+
+	total := 0
+	for _, file := range files {
+		count, err := s.importFile(file)
+		if err != nil {
+			return total, err
+		}
+		log.Printf("Imported %d prices from %s", count, file)
+		total += count
+	}
+	return total, nil
+}
+
+// importFile parses and stores one price CSV file
+func (s *priceService) importFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	defaultTicker := strings.ToUpper(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	prices, err := parsePriceCSV(file, defaultTicker)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := s.repo.UpsertPrices(prices); err != nil {
+		return 0, err
+	}
+	return len(prices), nil
+}
+
+// parsePriceCSV reads OHLCV rows from r, using defaultTicker when the file has no ticker column
+func parsePriceCSV(r io.Reader, defaultTicker string) ([]models.StockPrice, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	if _, ok := columns["ticker"]; !ok {
+		if i, ok := columns["symbol"]; ok {
+			columns["ticker"] = i
+		}
+	}
+	for _, required := range []string{"date", "close"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+
+	var prices []models.StockPrice
+	seen := make(map[string]int)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		price := models.StockPrice{Ticker: defaultTicker}
+		if ticker := field("ticker"); ticker != "" {
+			price.Ticker = strings.ToUpper(ticker)
+		}
+
+		if price.Date, err = parsePriceDate(field("date")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		// Rows without a usable close (e.g. holidays marked "null") are skipped
+		closePrice, err := strconv.ParseFloat(field("close"), 64)
+		if err != nil || closePrice <= 0 {
+			continue
+		}
+		price.Close = closePrice
+		price.Open = parseOptionalFloat(field("open"))
+		price.High = parseOptionalFloat(field("high"))
+		price.Low = parseOptionalFloat(field("low"))
+		price.Volume = int64(parseOptionalFloat(field("volume")))
+
+		// A repeated ticker and date replaces the earlier row, as one upsert cannot touch a row twice
+		key := price.Ticker + "|" + price.Date.Format("2006-01-02")
+		if i, ok := seen[key]; ok {
+			prices[i] = price
+			continue
+		}
+		seen[key] = len(prices)
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+// parsePriceDate parses a date in any of the accepted layouts
+func parsePriceDate(value string) (time.Time, error) {
+	for _, layout := range priceDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// parseOptionalFloat parses a number, returning 0 for empty or invalid values
+func parseOptionalFloat(value string) float64 {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
+
+// GetPrices retrieves a ticker's daily prices in a date range
+func (s *priceService) GetPrices(ticker string, from, to time.Time, limit int) ([]models.StockPrice, error) {
+	return s.repo.GetPrices(ticker, from, to, limit)
+}
+
+// GetLatestPrice retrieves the most recent price of a ticker
+func (s *priceService) GetLatestPrice(ticker string) (*models.StockPrice, error) {
+	return s.repo.GetLatestPrice(ticker)
+}
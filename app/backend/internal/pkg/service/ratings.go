@@ -0,0 +1,50 @@
+package service
+
+import "strings"
+
+// ratingValue maps a brokerage rating onto a 1 (strong sell) to 5 (strong buy) scale
+func ratingValue(rating string) (float64, bool) {
+	r := strings.ToLower(strings.TrimSpace(rating))
+	switch {
+	case r == "":
+		return 0, false
+	case strings.Contains(r, "strong buy") || strings.Contains(r, "conviction buy") || strings.Contains(r, "top pick"):
+		return 5, true
+	case strings.Contains(r, "strong sell"):
+		return 1, true
+	case strings.Contains(r, "sell") || strings.Contains(r, "underperform") || strings.Contains(r, "underweight") ||
+		strings.Contains(r, "reduce") || strings.Contains(r, "negative"):
+		return 2, true
+	case strings.Contains(r, "buy") || strings.Contains(r, "outperform") || strings.Contains(r, "overweight") ||
+		strings.Contains(r, "accumulate") || strings.Contains(r, "positive"):
+		return 4, true
+	case strings.Contains(r, "hold") || strings.Contains(r, "neutral") || strings.Contains(r, "equal") ||
+		strings.Contains(r, "perform") || strings.Contains(r, "in-line") || strings.Contains(r, "sector weight"):
+		return 3, true
+	}
+	return 0, false
+}
+
+// Rating classes used when counting ratings
+const (
+	ratingClassUnknown = iota
+	ratingClassBuy
+	ratingClassSell
+	ratingClassHold
+)
+
+// ratingClass classifies a target rating as buy, sell or hold from its ratingValue, so counts
+// agree with the consensus scale
+func ratingClass(rating string) int {
+	value, ok := ratingValue(rating)
+	switch {
+	case !ok:
+		return ratingClassUnknown
+	case value >= 4:
+		return ratingClassBuy
+	case value <= 2:
+		return ratingClassSell
+	default:
+		return ratingClassHold
+	}
+}
@@ -0,0 +1,41 @@
+package service
+
+import "testing"
+
+func TestRatingClassFollowsRatingValue(t *testing.T) {
+	tests := []struct {
+		rating string
+		value  float64
+		class  int
+	}{
+		{rating: "Strong Buy", value: 5, class: ratingClassBuy},
+		{rating: "Buy", value: 4, class: ratingClassBuy},
+		{rating: "Outperform", value: 4, class: ratingClassBuy},
+		{rating: "Overweight", value: 4, class: ratingClassBuy},
+		{rating: "Accumulate", value: 4, class: ratingClassBuy},
+		{rating: "Positive", value: 4, class: ratingClassBuy},
+		{rating: "Hold", value: 3, class: ratingClassHold},
+		{rating: "Neutral", value: 3, class: ratingClassHold},
+		{rating: "Sector Perform", value: 3, class: ratingClassHold},
+		{rating: "Equal-Weight", value: 3, class: ratingClassHold},
+		{rating: "In-Line", value: 3, class: ratingClassHold},
+		{rating: "Underperform", value: 2, class: ratingClassSell},
+		{rating: "Underweight", value: 2, class: ratingClassSell},
+		{rating: "Reduce", value: 2, class: ratingClassSell},
+		{rating: "Strong Sell", value: 1, class: ratingClassSell},
+		{rating: "", class: ratingClassUnknown},
+		{rating: "Not Rated", class: ratingClassUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rating, func(t *testing.T) {
+			value, ok := ratingValue(tt.rating)
+			if ok != (tt.value != 0) || value != tt.value {
+				t.Errorf("ratingValue = %v, %v, want %v", value, ok, tt.value)
+			}
+			if class := ratingClass(tt.rating); class != tt.class {
+				t.Errorf("ratingClass = %d, want %d", class, tt.class)
+			}
+		})
+	}
+}
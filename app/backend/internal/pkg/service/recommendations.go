@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"truora-backend/internal/pkg/models"
+)
+
+// GenerateOptions controls a recommendation run
+type GenerateOptions struct {
+	// Full rescores every ticker instead of only those with new events since the last run
+	Full bool
+}
+
+// TickerError reports a failure to score or store the recommendation of one ticker
+type TickerError struct {
+	Ticker string
+	Err    error
+}
+
+// Error implements the error interface
+func (e *TickerError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Ticker, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *TickerError) Unwrap() error {
+	return e.Err
+}
+
+// TickerErrors extracts the per-ticker failures from an error returned by GenerateRecommendations
+func TickerErrors(err error) []*TickerError {
+	var tickerErr *TickerError
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var result []*TickerError
+		for _, e := range joined.Unwrap() {
+			if errors.As(e, &tickerErr) {
+				result = append(result, tickerErr)
+			}
+		}
+		return result
+	}
+	if errors.As(err, &tickerErr) {
+		return []*TickerError{tickerErr}
+	}
+	return nil
+}
+
+// tickerJob is a ticker's events waiting to be scored
+type tickerJob struct {
+	ticker string
+	events []models.Stock
+}
+
+// tickerResult is the outcome of scoring one ticker, with one recommendation per time horizon
+type tickerResult struct {
+	ticker          string
+	recommendations []*models.StockRecommendation
+	err             error
+}
+
+// GenerateRecommendations generates stock recommendations based on analyst ratings and actions.
+// Unless a full rebuild is requested, only tickers with events added or changed since the last
+// completed run are rescored; the first run is always a full rebuild. Tickers are scored by a
+// pool of workers and stored in batched transactions. Per-ticker failures are collected and
+// returned joined together as *TickerError values; cancelling ctx stops the run mid-flight.
+func (s *stockService) GenerateRecommendations(ctx context.Context, opts GenerateOptions) (*models.RecommendationRun, error) {
+	lastRun, err := s.repo.GetLastCompletedRun()
+	if err != nil {
+		return nil, err
+	}
+
+	run := &models.RecommendationRun{
+		Full:      opts.Full || lastRun == nil,
+		Status:    models.RunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if !run.Full {
+		run.Since = &lastRun.StartedAt
+	}
+	if err := s.repo.CreateRecommendationRun(run); err != nil {
+		return nil, err
+	}
+
+	if run.Full {
+		log.Println("Generating stock recommendations (full rebuild)...")
+	} else {
+		log.Printf("Generating stock recommendations for events since %s...", run.Since.Format(time.RFC3339))
+	}
+
+	err = s.recomputeRecommendations(ctx, run)
+
+	// Recalibrate and snapshot every ticker whenever scores changed, since one ticker's move shifts the others
+	if run.TickerCount > 0 && ctx.Err() == nil {
+		if calibrateErr := s.repo.CalibrateRecommendations(ctx, s.config.GradeCutoffs); calibrateErr != nil && err == nil {
+			err = calibrateErr
+		}
+		if snapshotErr := s.repo.CreateRankSnapshots(ctx, run.ID, time.Now()); snapshotErr != nil && err == nil {
+			err = snapshotErr
+		}
+	}
+
+	return run, s.finishRun(run, err)
+}
+
+// recomputeRecommendations rescores the tickers affected by a run, recording counts on the run
+func (s *stockService) recomputeRecommendations(ctx context.Context, run *models.RecommendationRun) error {
+	// A nil ticker list streams every ticker
+	var tickers []string
+	if !run.Full {
+		updated, err := s.repo.GetTickersUpdatedSince(*run.Since)
+		if err != nil {
+			return err
+		}
+		if len(updated) == 0 {
+			log.Println("No new analyst events since the last run")
+			return nil
+		}
+		tickers = updated
+	}
+
+	inputs, err := s.loadScoringInputs()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan tickerJob, s.config.Workers)
+	results := make(chan tickerResult, s.config.Workers)
+
+	// Producer: stream ticker groups from the database
+	streamErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		streamErr <- s.repo.StreamTickerEvents(ctx, tickers, func(ticker string, events []models.Stock) error {
+			select {
+			case jobs <- tickerJob{ticker: ticker, events: events}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	// Workers: score ticker groups concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				recommendations, err := s.scoreTicker(job.ticker, job.events, inputs)
+				select {
+				case results <- tickerResult{ticker: job.ticker, recommendations: recommendations, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Collector: write results in batches, keeping each ticker's horizons in the same batch
+	var tickerErrs []error
+	var batchTickers []string
+	batch := make([]*models.StockRecommendation, 0, s.config.BatchSize+len(models.TimeHorizons))
+	flush := func() {
+		if len(batch) == 0 || ctx.Err() != nil {
+			return
+		}
+		if err := s.repo.UpsertRecommendations(ctx, batch); err != nil {
+			for _, ticker := range batchTickers {
+				tickerErrs = append(tickerErrs, &TickerError{Ticker: ticker, Err: err})
+			}
+		} else {
+			run.TickerCount += len(batchTickers)
+		}
+		batch = batch[:0]
+		batchTickers = batchTickers[:0]
+	}
+
+	for result := range results {
+		if result.err != nil {
+			tickerErrs = append(tickerErrs, &TickerError{Ticker: result.ticker, Err: result.err})
+			continue
+		}
+		batch = append(batch, result.recommendations...)
+		batchTickers = append(batchTickers, result.ticker)
+		if len(batch) >= s.config.BatchSize {
+			flush()
+		}
+	}
+	flush()
+	run.ErrorCount = len(tickerErrs)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := <-streamErr; err != nil {
+		return fmt.Errorf("failed to get stocks for analysis: %w", err)
+	}
+
+	if run.Full && run.TickerCount == 0 && len(tickerErrs) == 0 {
+		return fmt.Errorf("no stocks available for analysis")
+	}
+
+	log.Printf("Generated recommendations for %d tickers (%d failed)", run.TickerCount, len(tickerErrs))
+	return errors.Join(tickerErrs...)
+}
+
+// finishRun records the outcome of a run and returns runErr
+func (s *stockService) finishRun(run *models.RecommendationRun, runErr error) error {
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+
+	var tickerErr *TickerError
+	switch {
+	case runErr == nil:
+		run.Status = models.RunStatusCompleted
+	case errors.Is(runErr, context.Canceled) || errors.Is(runErr, context.DeadlineExceeded):
+		run.Status = models.RunStatusCancelled
+		run.Error = runErr.Error()
+	case errors.As(runErr, &tickerErr):
+		// Partial runs are not used as the watermark, so their tickers are retried next time
+		run.Status = models.RunStatusPartial
+		run.Error = runErr.Error()
+	default:
+		run.Status = models.RunStatusFailed
+		run.Error = runErr.Error()
+	}
+
+	if err := s.repo.UpdateRecommendationRun(run); err != nil {
+		if runErr != nil {
+			return runErr
+		}
+		return err
+	}
+	return runErr
+}
+
+// loadScoringInputs gathers the profile and brokerage credibility weights for a run
+func (s *stockService) loadScoringInputs() (scoringInputs, error) {
+	inputs := scoringInputs{Profile: s.config.Profile, AsOf: time.Now()}
+	if !s.config.UseCredibility {
+		return inputs, nil
+	}
+
+	scores, err := s.brokerages.GetAllScores()
+	if err != nil {
+		return inputs, err
+	}
+	inputs.Credibility = make(map[string]float64, len(scores))
+	for _, score := range scores {
+		inputs.Credibility[strings.ToLower(strings.TrimSpace(score.Brokerage))] = score.Credibility
+	}
+	return inputs, nil
+}
+
+// scoreTicker builds one recommendation per time horizon from all analyst events of a ticker
+func (s *stockService) scoreTicker(ticker string, tickerStocks []models.Stock, inputs scoringInputs) ([]*models.StockRecommendation, error) {
+	if len(tickerStocks) == 0 {
+		return nil, fmt.Errorf("no analyst events to score")
+	}
+
+	// Returns are measured against the latest close, or against prior targets without prices
+	latestPrice, err := s.prices.GetLatestPrice(ticker)
+	if err != nil {
+		return nil, err
+	}
+	referencePrice := 0.0
+	if latestPrice != nil {
+		referencePrice = latestPrice.Close
+	}
+
+	recommendations := make([]*models.StockRecommendation, 0, len(models.TimeHorizons))
+	for _, horizon := range models.TimeHorizons {
+		recommendations = append(recommendations, s.evaluateTicker(ticker, tickerStocks, referencePrice, inputs, horizon))
+	}
+	return recommendations, nil
+}
+
+// tickerScorer scores a ticker's events the way the recommendation engine does, without I/O
+type tickerScorer interface {
+	evaluateTicker(ticker string, tickerStocks []models.Stock, referencePrice float64,
+		inputs scoringInputs, horizon string) *models.StockRecommendation
+}
+
+// newTickerScorer creates a scorer with a scoring config for callers that replay historical
+// snapshots of the events, such as backtests
+func newTickerScorer(config Config) tickerScorer {
+	return &stockService{config: config}
+}
+
+// evaluateTicker scores a ticker's events for a time horizon against a reference price using a
+// scoring profile and credibility weights. It does no I/O, so it can also score historical
+// snapshots of the events.
+func (s *stockService) evaluateTicker(ticker string, tickerStocks []models.Stock, referencePrice float64,
+	inputs scoringInputs, horizon string) *models.StockRecommendation {
+	// Use the most recent stock data
+	latestStock := tickerStocks[0]
+	for _, stock := range tickerStocks {
+		if stock.Time.After(latestStock.Time) {
+			latestStock = stock
+		}
+	}
+
+	estimate := s.calculateExpectedReturn(tickerStocks, referencePrice)
+	score := s.calculateRecommendationScore(tickerStocks, estimate, inputs, horizon)
+	interval := s.estimateScoreInterval(ticker, tickerStocks, referencePrice, inputs, horizon, score)
+	risk := s.calculateRiskLevel(tickerStocks)
+	reason := s.generateReason(tickerStocks, score, estimate)
+	sentiment := s.calculateAnalystSentiment(tickerStocks)
+	consensus := s.buildConsensus(ticker, tickerStocks, referencePrice)
+	upgradeCount, downgradeCount := s.countUpgradesDowngrades(tickerStocks)
+
+	return &models.StockRecommendation{
+		StockID:             latestStock.ID,
+		Ticker:              ticker,
+		RecommendationScore: score,
+		ScoreLower:          interval.Lower,
+		ScoreUpper:          interval.Upper,
+		Confidence:          interval.Confidence,
+		RiskLevel:           risk.Level,
+		RiskScore:           risk.Score,
+		ExpectedReturn:      estimate.ExpectedReturn,
+		ExpectedReturnLow:   estimate.ReturnLow,
+		ExpectedReturnHigh:  estimate.ReturnHigh,
+		Upside:              estimate.Upside,
+		ReferencePrice:      optionalPrice(referencePrice),
+		TargetCount:         estimate.Count,
+		TargetMean:          estimate.Mean,
+		TargetMedian:        estimate.Median,
+		TargetHigh:          estimate.High,
+		TargetLow:           estimate.Low,
+		TargetChange:        estimate.TargetChange,
+		TimeHorizon:         horizon,
+		Reason:              reason,
+		AnalystSentiment:    sentiment,
+		ConsensusRating:     consensus.ConsensusRating,
+		BrokerageCount:      consensus.BrokerageCount,
+		UpgradeCount:        upgradeCount,
+		DowngradeCount:      downgradeCount,
+	}
+}
+
+// GetRecommendationHistory retrieves a ticker's rank and score over the last days, for one
+// time horizon or for all of them when horizon is empty
+func (s *stockService) GetRecommendationHistory(ticker, horizon string, days int) ([]models.RecommendationSnapshot, error) {
+	return s.repo.GetRankHistory(ticker, horizon, time.Now().AddDate(0, 0, -days))
+}
+
+// GetTopMovers retrieves the recommendations whose score changed the most over the last days
+func (s *stockService) GetTopMovers(days int, filter models.RecommendationFilter) ([]models.StockRecommendation, error) {
+	return s.repo.GetTopMovers(time.Now().AddDate(0, 0, -days), filter)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"truora-backend/internal/pkg/models"
+)
+
+// Risk component weights; together they add up to 1
+const (
+	riskWeightRatingSpread = 0.30
+	riskWeightTargetCV     = 0.30
+	riskWeightFlips        = 0.20
+	riskWeightCoverage     = 0.20
+
+	// fullCoverageBrokerages is the number of covering brokerages at which coverage adds no risk
+	fullCoverageBrokerages = 5
+)
+
+// riskAssessment holds a 0-100 risk score and its bucket
+type riskAssessment struct {
+	Score float64
+	Level string
+}
+
+// assessRisk scores analyst disagreement for a ticker's events and buckets it
+// using the configured thresholds
+func assessRisk(stocks []models.Stock, config Config) riskAssessment {
+	latest := latestByBrokerage(stocks)
+
+	score := riskWeightRatingSpread*ratingSpreadRisk(latest) +
+		riskWeightTargetCV*targetDispersionRisk(latest) +
+		riskWeightFlips*ratingFlipRisk(stocks) +
+		riskWeightCoverage*coverageRisk(len(latest))
+	score = math.Round(score*10000) / 100
+
+	level := "medium"
+	if score < config.RiskLowThreshold {
+		level = "low"
+	} else if score >= config.RiskHighThreshold {
+		level = "high"
+	}
+	return riskAssessment{Score: score, Level: level}
+}
+
+// ratingSpreadRisk measures how far apart the current brokerage ratings are (0-1).
+// Unknown ratings count as maximum uncertainty.
+func ratingSpreadRisk(latest []models.Stock) float64 {
+	var values []float64
+	for _, stock := range latest {
+		if value, ok := ratingValue(stock.RatingTo); ok {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return 1
+	}
+	// The largest possible standard deviation on a 1-5 scale is 2
+	return math.Min(stddev(values)/2, 1)
+}
+
+// targetDispersionRisk uses the coefficient of variation of current price targets (0-1).
+// A CV of 50% or more is treated as maximum disagreement.
+func targetDispersionRisk(latest []models.Stock) float64 {
+	var targets []float64
+	for _, stock := range latest {
+		if target, ok := models.ParseTargetPrice(stock.TargetTo); ok {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return 1
+	}
+	m := mean(targets)
+	if m == 0 {
+		return 1
+	}
+	return math.Min(stddev(targets)/m/0.5, 1)
+}
+
+// ratingFlipRisk measures how often brokerages reverse the direction of their rating changes (0-1)
+func ratingFlipRisk(stocks []models.Stock) float64 {
+	byBrokerage := make(map[string][]models.Stock)
+	for _, stock := range stocks {
+		key := strings.ToLower(strings.TrimSpace(stock.Brokerage))
+		byBrokerage[key] = append(byBrokerage[key], stock)
+	}
+
+	flips := 0
+	transitions := 0
+	for _, events := range byBrokerage {
+		sort.Slice(events, func(i, j int) bool {
+			return events[i].Time.Before(events[j].Time)
+		})
+
+		previous := 0
+		for _, event := range events {
+			direction := ratingDirection(event)
+			if direction == 0 {
+				continue
+			}
+			if previous != 0 {
+				transitions++
+				if direction != previous {
+					flips++
+				}
+			}
+			previous = direction
+		}
+	}
+
+	if transitions == 0 {
+		return 0
+	}
+	return float64(flips) / float64(transitions)
+}
+
+// ratingDirection returns 1 for an upgrade, -1 for a downgrade and 0 otherwise
+func ratingDirection(stock models.Stock) int {
+	from, okFrom := ratingValue(stock.RatingFrom)
+	to, okTo := ratingValue(stock.RatingTo)
+	if okFrom && okTo && from != to {
+		if to > from {
+			return 1
+		}
+		return -1
+	}
+
+	action := strings.ToLower(stock.Action)
+	if strings.Contains(action, "upgrade") {
+		return 1
+	} else if strings.Contains(action, "downgrade") {
+		return -1
+	}
+	return 0
+}
+
+// coverageRisk is highest for a single covering brokerage and zero at full coverage (0-1)
+func coverageRisk(brokerages int) float64 {
+	if brokerages <= 1 {
+		return 1
+	}
+	if brokerages >= fullCoverageBrokerages {
+		return 0
+	}
+	return float64(fullCoverageBrokerages-brokerages) / float64(fullCoverageBrokerages-1)
+}
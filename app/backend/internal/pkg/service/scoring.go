@@ -0,0 +1,196 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+)
+
+// ScoringProfile weights the signals that make up a recommendation score
+type ScoringProfile struct {
+	Name string `json:"name"`
+	// UpgradeWeight is the number of points per net upgrade
+	UpgradeWeight float64 `json:"upgrade_weight"`
+	// RatingWeight is the number of points for an all-buy (or minus for all-sell) rating mix
+	RatingWeight float64 `json:"rating_weight"`
+	// ReturnWeight is the number of points per percent of target-implied return
+	ReturnWeight float64 `json:"return_weight"`
+	// MaxReturnPoints caps the points the expected return can add or remove
+	MaxReturnPoints float64 `json:"max_return_points"`
+}
+
+// scoringProfiles are the built-in profiles, keyed by name
+var scoringProfiles = map[string]ScoringProfile{
+	"default": {
+		Name:          "default",
+		UpgradeWeight: 10,
+		RatingWeight:  30,
+	},
+	"consensus": {
+		Name:            "consensus",
+		UpgradeWeight:   5,
+		RatingWeight:    45,
+		ReturnWeight:    0.25,
+		MaxReturnPoints: 10,
+	},
+	"momentum": {
+		Name:            "momentum",
+		UpgradeWeight:   15,
+		RatingWeight:    15,
+		ReturnWeight:    0.5,
+		MaxReturnPoints: 15,
+	},
+	"targets": {
+		Name:            "targets",
+		UpgradeWeight:   5,
+		RatingWeight:    20,
+		ReturnWeight:    1,
+		MaxReturnPoints: 25,
+	},
+}
+
+// LookupScoringProfile returns the built-in scoring profile with the given name
+func LookupScoringProfile(name string) (ScoringProfile, bool) {
+	profile, ok := scoringProfiles[name]
+	return profile, ok
+}
+
+// ScoringProfileNames returns the names of the built-in scoring profiles in alphabetical order
+func ScoringProfileNames() []string {
+	names := make([]string, 0, len(scoringProfiles))
+	for name := range scoringProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scoringInputs are the run-wide settings a ticker is scored with
+type scoringInputs struct {
+	Profile ScoringProfile
+	// Credibility maps lower-case brokerage names to weights; missing brokerages weigh 1
+	Credibility map[string]float64
+	// AsOf is the moment event ages are measured from for recency decay
+	AsOf time.Time
+}
+
+// scoreSignals are weighted counts of analyst actions and ratings
+type scoreSignals struct {
+	Upgrades    float64
+	Downgrades  float64
+	Buys        float64
+	Sells       float64
+	Holds       float64
+	Initiations float64 // bullish minus bearish coverage initiations
+	// TargetChange is the weighted average % change of target_to versus target_from
+	TargetChange float64
+}
+
+// weighSignals counts each event's action and rating, weighted by its brokerage's credibility
+// and by its age under the horizon's recency decay
+func weighSignals(stocks []models.Stock, inputs scoringInputs, horizon horizonWeights) scoreSignals {
+	var signals scoreSignals
+	weightOf := func(stock models.Stock) float64 {
+		weight := horizon.decay(inputs.AsOf, stock.Time)
+		if value, ok := inputs.Credibility[strings.ToLower(strings.TrimSpace(stock.Brokerage))]; ok {
+			weight *= value
+		}
+		return weight
+	}
+	countRating := func(stock models.Stock, weight float64) {
+		switch ratingClass(stock.RatingTo) {
+		case ratingClassBuy:
+			signals.Buys += weight
+		case ratingClassSell:
+			signals.Sells += weight
+		case ratingClassHold:
+			signals.Holds += weight
+		}
+	}
+
+	var changeSum, changeWeight float64
+	for _, stock := range stocks {
+		weight := weightOf(stock)
+
+		switch actionDirection(stock.Action) {
+		case 1:
+			signals.Upgrades += weight
+		case -1:
+			signals.Downgrades += weight
+		}
+
+		if isInitiation(stock.Action) {
+			switch ratingClass(stock.RatingTo) {
+			case ratingClassBuy:
+				signals.Initiations += weight
+			case ratingClassSell:
+				signals.Initiations -= weight
+			}
+		}
+
+		from, okFrom := models.ParseTargetPrice(stock.TargetFrom)
+		to, okTo := models.ParseTargetPrice(stock.TargetTo)
+		if okFrom && okTo {
+			changeSum += (to - from) / from * 100 * weight
+			changeWeight += weight
+		}
+
+		if !horizon.LatestRatings {
+			countRating(stock, weight)
+		}
+	}
+
+	if horizon.LatestRatings {
+		for _, stock := range latestByBrokerage(stocks) {
+			countRating(stock, weightOf(stock))
+		}
+	}
+	if changeWeight > 0 {
+		signals.TargetChange = changeSum / changeWeight
+	}
+	return signals
+}
+
+// combineScore turns weighted signals into a 0-100 score using a profile and horizon weights
+func combineScore(profile ScoringProfile, horizon horizonWeights, signals scoreSignals, expectedReturn float64) float64 {
+	score := 50.0 // Base score
+
+	// Net upgrades
+	score += (signals.Upgrades - signals.Downgrades) * profile.UpgradeWeight * horizon.UpgradeScale
+
+	// Rating distribution
+	totalRatings := signals.Buys + signals.Sells + signals.Holds
+	if totalRatings > 0 {
+		buyPercentage := signals.Buys / totalRatings
+		sellPercentage := signals.Sells / totalRatings
+		score += (buyPercentage - sellPercentage) * profile.RatingWeight * horizon.RatingScale
+	}
+
+	// Target-implied return
+	if profile.ReturnWeight != 0 {
+		score += capPoints(expectedReturn*profile.ReturnWeight, profile.MaxReturnPoints)
+	}
+
+	// Recent target revisions
+	if horizon.TargetChangeWeight != 0 {
+		score += capPoints(signals.TargetChange*horizon.TargetChangeWeight, horizon.MaxTargetChangePoints)
+	}
+
+	// New coverage
+	if horizon.InitiationWeight != 0 {
+		score += capPoints(signals.Initiations*horizon.InitiationWeight, horizon.MaxInitiationPoints)
+	}
+
+	// Ensure score is within bounds
+	return math.Max(0, math.Min(100, score))
+}
+
+// capPoints limits points to ±max; a max of 0 leaves them uncapped
+func capPoints(points, max float64) float64 {
+	if max > 0 {
+		return math.Max(-max, math.Min(max, points))
+	}
+	return points
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/repository"
+)
+
+type SectorService interface {
+	ImportCSV(path string) (int, error)
+	GetSectors(horizon string, days, topPicks int) ([]models.SectorSummary, error)
+	GetSector(sector, horizon string, days, topPicks int) (*models.SectorSummary, error)
+}
+
+type sectorService struct {
+	repo repository.SectorRepository
+}
+
+// NewSectorService creates a new sector service
+func NewSectorService(repo repository.SectorRepository) SectorService {
+	return &sectorService{repo: repo}
+}
+
+// ImportCSV loads ticker classifications from a CSV file with ticker (or symbol), sector and
+// industry columns. Tickers already classified take the sector and industry of the file.
+func (s *sectorService) ImportCSV(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open sector data: %w", err)
+	}
+	defer file.Close()
+
+	sectors, err := parseSectorCSV(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := s.repo.UpsertSectors(sectors); err != nil {
+		return 0, err
+	}
+	log.Printf("Imported %d ticker sectors from %s", len(sectors), path)
+	return len(sectors), nil
+}
+
+// parseSectorCSV reads ticker classifications from r, skipping rows without a ticker or sector
+func parseSectorCSV(r io.Reader) ([]models.TickerSector, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	if _, ok := columns["ticker"]; !ok {
+		if i, ok := columns["symbol"]; ok {
+			columns["ticker"] = i
+		}
+	}
+	for _, required := range []string{"ticker", "sector"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+
+	var sectors []models.TickerSector
+	seen := make(map[string]int)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		sector := models.TickerSector{
+			Ticker:   strings.ToUpper(field("ticker")),
+			Sector:   field("sector"),
+			Industry: field("industry"),
+		}
+		if sector.Ticker == "" || sector.Sector == "" {
+			continue
+		}
+
+		// A repeated ticker replaces the earlier row, as one upsert cannot touch a row twice
+		if i, ok := seen[sector.Ticker]; ok {
+			sectors[i] = sector
+			continue
+		}
+		seen[sector.Ticker] = len(sectors)
+		sectors = append(sectors, sector)
+	}
+	return sectors, nil
+}
+
+// GetSectors summarizes every classified sector for a time horizon, best average score first.
+// Upgrades and downgrades are counted over the last days; each sector lists its topPicks best
+// recommendations.
+func (s *sectorService) GetSectors(horizon string, days, topPicks int) ([]models.SectorSummary, error) {
+	return s.summarize("", horizon, days, topPicks)
+}
+
+// GetSector summarizes one sector for a time horizon, or returns nil when it has no recommendations
+func (s *sectorService) GetSector(sector, horizon string, days, topPicks int) (*models.SectorSummary, error) {
+	summaries, err := s.summarize(sector, horizon, days, topPicks)
+	if err != nil || len(summaries) == 0 {
+		return nil, err
+	}
+	return &summaries[0], nil
+}
+
+// summarize merges recommendation stats, recent activity and top picks per sector
+func (s *sectorService) summarize(sector, horizon string, days, topPicks int) ([]models.SectorSummary, error) {
+	summaries, err := s.repo.GetSectorStats(horizon, sector)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := s.repo.GetSectorActivity(time.Now().AddDate(0, 0, -days), sector)
+	if err != nil {
+		return nil, err
+	}
+	picks, err := s.repo.GetSectorTopPicks(horizon, sector, topPicks)
+	if err != nil {
+		return nil, err
+	}
+
+	activityBySector := make(map[string]models.SectorSummary, len(activity))
+	for _, counts := range activity {
+		activityBySector[counts.Sector] = counts
+	}
+	picksBySector := make(map[string][]models.StockRecommendation)
+	for _, pick := range picks {
+		picksBySector[pick.Sector] = append(picksBySector[pick.Sector], pick)
+	}
+
+	for i := range summaries {
+		summary := &summaries[i]
+		summary.AvgScore = round2(summary.AvgScore)
+		summary.UpgradeCount = activityBySector[summary.Sector].UpgradeCount
+		summary.DowngradeCount = activityBySector[summary.Sector].DowngradeCount
+		summary.NetUpgrades = summary.UpgradeCount - summary.DowngradeCount
+		summary.Sentiment = sectorSentiment(*summary)
+		summary.TopPicks = picksBySector[summary.Sector]
+		if summary.TopPicks == nil {
+			summary.TopPicks = []models.StockRecommendation{}
+		}
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].AvgScore > summaries[j].AvgScore
+	})
+	return summaries, nil
+}
+
+// sectorSentiment calls a sector bullish or bearish when its tickers' sentiment and its recent
+// rating changes agree, and neutral otherwise
+func sectorSentiment(summary models.SectorSummary) string {
+	if summary.BullishCount > summary.BearishCount && summary.NetUpgrades >= 0 {
+		return "bullish"
+	} else if summary.BearishCount > summary.BullishCount && summary.NetUpgrades <= 0 {
+		return "bearish"
+	}
+	return "neutral"
+}
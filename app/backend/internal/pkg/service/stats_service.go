@@ -0,0 +1,123 @@
+package service
+
+import (
+	"sync"
+	"time"
+	"truora-backend/internal/pkg/models"
+	"truora-backend/internal/pkg/repository"
+)
+
+// overviewActivityDays are the windows the overview counts recent events over
+var overviewActivityDays = []int{1, 7, 30}
+
+// overviewTopCount is the number of brokerages and tickers the overview ranks
+const overviewTopCount = 10
+
+type StatsService interface {
+	GetOverview() (*models.MarketOverview, error)
+}
+
+type statsService struct {
+	repo repository.StatsRepository
+	ttl  time.Duration
+
+	mu       sync.Mutex
+	overview *models.MarketOverview
+	version  models.DataVersion // data version the cached overview was built from
+	expires  time.Time
+}
+
+// NewStatsService creates a new stats service. The overview is cached until an ingestion changes
+// the stored events, a recommendation run completes or ttl passes, whichever comes first.
+func NewStatsService(repo repository.StatsRepository, ttl time.Duration) StatsService {
+	return &statsService{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// GetOverview returns the market-wide overview, rebuilding it when the data changed since it was
+// cached or the cache expired
+func (s *statsService) GetOverview() (*models.MarketOverview, error) {
+	version, err := s.repo.GetDataVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	// Holding the lock while rebuilding lets concurrent requests share one rebuild
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.overview != nil && s.version.Equal(version) && time.Now().Before(s.expires) {
+		return s.overview, nil
+	}
+
+	overview, err := s.buildOverview()
+	if err != nil {
+		return nil, err
+	}
+	s.overview = overview
+	s.version = version
+	s.expires = overview.GeneratedAt.Add(s.ttl)
+	return overview, nil
+}
+
+// buildOverview computes every aggregate of the overview
+func (s *statsService) buildOverview() (*models.MarketOverview, error) {
+	now := time.Now()
+	overview, err := s.repo.GetTotals()
+	if err != nil {
+		return nil, err
+	}
+	overview.GeneratedAt = now
+
+	for _, days := range overviewActivityDays {
+		activity, err := s.repo.GetActivity(now.AddDate(0, 0, -days))
+		if err != nil {
+			return nil, err
+		}
+		activity.Days = days
+		overview.Activity = append(overview.Activity, *activity)
+	}
+
+	longest := overviewActivityDays[len(overviewActivityDays)-1]
+	if overview.MostActiveBrokerages, err = s.repo.GetMostActiveBrokerages(now.AddDate(0, 0, -longest), overviewTopCount); err != nil {
+		return nil, err
+	}
+	for i := range overview.MostActiveBrokerages {
+		overview.MostActiveBrokerages[i].ID = brokerageSlug(overview.MostActiveBrokerages[i].Name)
+	}
+	if overview.MostCoveredTickers, err = s.repo.GetMostCoveredTickers(overviewTopCount); err != nil {
+		return nil, err
+	}
+
+	ratings, err := s.repo.GetCurrentRatingCounts()
+	if err != nil {
+		return nil, err
+	}
+	for rating, count := range ratings {
+		value, ok := ratingValue(rating)
+		if !ok {
+			overview.RatingDistribution.Unrated += count
+			continue
+		}
+		switch value {
+		case 5:
+			overview.RatingDistribution.StrongBuy += count
+		case 4:
+			overview.RatingDistribution.Buy += count
+		case 3:
+			overview.RatingDistribution.Hold += count
+		case 2:
+			overview.RatingDistribution.Sell += count
+		case 1:
+			overview.RatingDistribution.StrongSell += count
+		}
+	}
+
+	freshness, err := s.repo.GetFreshness()
+	if err != nil {
+		return nil, err
+	}
+	overview.Freshness = *freshness
+	return overview, nil
+}
@@ -1,10 +1,10 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -15,17 +15,27 @@ import (
 type StockService interface {
 	FetchAndStoreStocks() error
 	GetAllStocks(limit, offset int) ([]models.Stock, error)
-	GetByTicker(ticker string) (*models.Stock, error)
+	GetStocks(filter models.StockFilter, withTotal bool) (*models.StockPage, error)
+	StreamStocks(ctx context.Context, filter models.StockFilter, fn func(stock models.Stock) error) error
 	SearchStocks(query string, limit, offset int) ([]models.Stock, error)
-	GenerateRecommendations() error
-	GetTopRecommendations(limit int) ([]models.StockRecommendation, error)
+	GenerateRecommendations(ctx context.Context, opts GenerateOptions) (*models.RecommendationRun, error)
+	GetTopRecommendations(filter models.RecommendationFilter, withTotal bool) (*models.RecommendationPage, error)
+	StreamRecommendations(ctx context.Context, filter models.RecommendationFilter, fn func(recommendation models.StockRecommendation) error) error
 	GetStockCount() (int64, error)
+	GetConsensus(ticker string) (*models.Consensus, error)
+	GetStockDetail(ticker, horizon string, days int, filter models.TimelineFilter) (*models.StockDetail, error)
+	GetRecommendationHistory(ticker, horizon string, days int) ([]models.RecommendationSnapshot, error)
+	GetTopMovers(days int, filter models.RecommendationFilter) ([]models.StockRecommendation, error)
 }
 
 type stockService struct {
-	repo   repository.StockRepository
-	apiURL string
-	apiKey string
+	repo       repository.StockRepository
+	prices     repository.PriceRepository
+	brokerages repository.BrokerageRepository
+	sectors    repository.SectorRepository
+	apiURL     string
+	apiKey     string
+	config     Config
 }
 
 // ExternalStockData represents the structure of data from external API
@@ -48,11 +58,16 @@ type ExternalAPIResponse struct {
 }
 
 // NewStockService creates a new stock service
-func NewStockService(repo repository.StockRepository, apiURL, apiKey string) StockService {
+func NewStockService(repo repository.StockRepository, prices repository.PriceRepository, brokerages repository.BrokerageRepository,
+	sectors repository.SectorRepository, apiURL, apiKey string, config Config) StockService {
 	return &stockService{
-		repo:   repo,
-		apiURL: apiURL,
-		apiKey: apiKey,
+		repo:       repo,
+		prices:     prices,
+		brokerages: brokerages,
+		sectors:    sectors,
+		apiURL:     apiURL,
+		apiKey:     apiKey,
+		config:     config,
 	}
 }
 
@@ -182,173 +197,74 @@ func (s *stockService) GetAllStocks(limit, offset int) ([]models.Stock, error) {
 	return s.repo.GetAll(limit, offset)
 }
 
-// GetByTicker retrieves a stock by its ticker
-func (s *stockService) GetByTicker(ticker string) (*models.Stock, error) {
-	return s.repo.GetByTicker(ticker)
-}
-
-// SearchStocks searches stocks by query
-func (s *stockService) SearchStocks(query string, limit, offset int) ([]models.Stock, error) {
-	return s.repo.SearchStocks(query, limit, offset)
-}
-
-// GetStockCount returns the total number of stocks
-func (s *stockService) GetStockCount() (int64, error) {
-	return s.repo.GetStockCount()
-}
-
-// GenerateRecommendations generates stock recommendations based on analyst ratings and actions
-func (s *stockService) GenerateRecommendations() error {
-	log.Println("Generating stock recommendations...")
-
-	// Get all stocks
-	stocks, err := s.repo.GetAll(10000, 0) // Get a large number to analyze all
+// GetStocks retrieves a page of the analyst events matching a filter with the cursor of the next
+// page and, when withTotal is set, the number of matching events
+func (s *stockService) GetStocks(filter models.StockFilter, withTotal bool) (*models.StockPage, error) {
+	// One extra row tells whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	stocks, total, err := s.repo.GetStocks(filter, withTotal)
 	if err != nil {
-		return fmt.Errorf("failed to get stocks for analysis: %w", err)
-	}
-
-	if len(stocks) == 0 {
-		return fmt.Errorf("no stocks available for analysis")
+		return nil, err
 	}
 
-	// Group stocks by ticker to analyze multiple analyst opinions
-	stockGroups := make(map[string][]models.Stock)
-	for _, stock := range stocks {
-		stockGroups[stock.Ticker] = append(stockGroups[stock.Ticker], stock)
+	page := &models.StockPage{Stocks: stocks}
+	if withTotal {
+		page.Total = &total
 	}
-
-	// Generate recommendations for each ticker
-	for ticker, tickerStocks := range stockGroups {
-		if len(tickerStocks) == 0 {
-			continue
-		}
-
-		// Use the most recent stock data
-		latestStock := tickerStocks[0]
-		for _, stock := range tickerStocks {
-			if stock.Time.After(latestStock.Time) {
-				latestStock = stock
-			}
-		}
-
-		score := s.calculateRecommendationScore(tickerStocks)
-		riskLevel := s.calculateRiskLevel(tickerStocks)
-		expectedReturn := s.calculateExpectedReturn(tickerStocks)
-		reason := s.generateReason(tickerStocks, score)
-		sentiment := s.calculateAnalystSentiment(tickerStocks)
-		upgradeCount, downgradeCount := s.countUpgradesDowngrades(tickerStocks)
-
-		recommendation := &models.StockRecommendation{
-			StockID:             latestStock.ID,
-			RecommendationScore: score,
-			RiskLevel:           riskLevel,
-			ExpectedReturn:      expectedReturn,
-			TimeHorizon:         "medium",
-			Reason:              reason,
-			AnalystSentiment:    sentiment,
-			UpgradeCount:        upgradeCount,
-			DowngradeCount:      downgradeCount,
-		}
-
-		if err := s.repo.CreateRecommendation(recommendation); err != nil {
-			log.Printf("Failed to store recommendation for %s: %v", ticker, err)
-		}
-	}
-
-	log.Printf("Generated recommendations for %d tickers", len(stockGroups))
-	return nil
-}
-
-// calculateRecommendationScore calculates a recommendation score based on analyst ratings and actions
-func (s *stockService) calculateRecommendationScore(stocks []models.Stock) float64 {
-	score := 50.0 // Base score
-	upgradeCount := 0
-	downgradeCount := 0
-	buyRatings := 0
-	sellRatings := 0
-	holdRatings := 0
-
-	// Analyze all analyst actions for this ticker
-	for _, stock := range stocks {
-		// Count upgrades and downgrades
-		if strings.Contains(strings.ToLower(stock.Action), "upgrade") {
-			upgradeCount++
-		} else if strings.Contains(strings.ToLower(stock.Action), "downgrade") {
-			downgradeCount++
-		}
-
-		// Analyze target ratings
-		ratingTo := strings.ToLower(stock.RatingTo)
-		if strings.Contains(ratingTo, "buy") || strings.Contains(ratingTo, "outperform") || strings.Contains(ratingTo, "strong buy") {
-			buyRatings++
-		} else if strings.Contains(ratingTo, "sell") || strings.Contains(ratingTo, "underperform") || strings.Contains(ratingTo, "strong sell") {
-			sellRatings++
-		} else if strings.Contains(ratingTo, "hold") || strings.Contains(ratingTo, "neutral") {
-			holdRatings++
-		}
-	}
-
-	// Calculate score based on analyst sentiment (60% weight)
-	if upgradeCount > downgradeCount {
-		score += float64(upgradeCount-downgradeCount) * 10
-	} else if downgradeCount > upgradeCount {
-		score -= float64(downgradeCount-upgradeCount) * 10
+	if len(stocks) <= limit {
+		return page, nil
 	}
+	page.Stocks = stocks[:limit]
 
-	// Rating distribution (40% weight)
-	totalRatings := buyRatings + sellRatings + holdRatings
-	if totalRatings > 0 {
-		buyPercentage := float64(buyRatings) / float64(totalRatings)
-		sellPercentage := float64(sellRatings) / float64(totalRatings)
-		score += (buyPercentage - sellPercentage) * 30
+	sort := filter.Sort
+	if len(sort) == 0 {
+		sort = models.DefaultStockSort
 	}
-
-	// Ensure score is within bounds
-	if score > 100 {
-		score = 100
-	} else if score < 0 {
-		score = 0
+	last := page.Stocks[limit-1]
+	page.Next = &models.Cursor{Sort: sort, ID: last.ID}
+	for _, key := range sort {
+		page.Next.Values = append(page.Next.Values, last.SortValue(key.Field))
 	}
+	return page, nil
+}
 
-	return score
+// StreamStocks calls fn with every analyst event matching a filter, upside included, in the
+// filter's sort order, reading them from the database one at a time
+func (s *stockService) StreamStocks(ctx context.Context, filter models.StockFilter, fn func(stock models.Stock) error) error {
+	return s.repo.StreamStocks(ctx, filter, true, fn)
 }
 
-// calculateRiskLevel determines risk level based on analyst consensus
-func (s *stockService) calculateRiskLevel(stocks []models.Stock) string {
-	upgradeCount, downgradeCount := s.countUpgradesDowngrades(stocks)
-	totalActions := upgradeCount + downgradeCount
+// SearchStocks searches stocks by query
+func (s *stockService) SearchStocks(query string, limit, offset int) ([]models.Stock, error) {
+	return s.repo.SearchStocks(query, limit, offset)
+}
 
-	if totalActions == 0 {
-		return "medium"
-	}
+// GetStockCount returns the total number of stocks
+func (s *stockService) GetStockCount() (int64, error) {
+	return s.repo.GetStockCount()
+}
 
-	upgradeRatio := float64(upgradeCount) / float64(totalActions)
-	if upgradeRatio > 0.7 {
-		return "low"
-	} else if upgradeRatio < 0.3 {
-		return "high"
-	}
-	return "medium"
+// calculateRecommendationScore calculates a recommendation score based on analyst ratings,
+// actions and price targets, weighted by a scoring profile, brokerage credibility and time horizon
+func (s *stockService) calculateRecommendationScore(stocks []models.Stock, estimate targetEstimate, inputs scoringInputs, horizon string) float64 {
+	weights := horizonWeightsByName[horizon]
+	signals := weighSignals(stocks, inputs, weights)
+	return combineScore(inputs.Profile, weights, signals, estimate.ExpectedReturn)
 }
 
-// calculateExpectedReturn estimates expected return based on target prices
-func (s *stockService) calculateExpectedReturn(stocks []models.Stock) float64 {
-	if len(stocks) == 0 {
-		return 0.0
-	}
+// calculateRiskLevel scores analyst disagreement and buckets it into low, medium or high
+func (s *stockService) calculateRiskLevel(stocks []models.Stock) riskAssessment {
+	return assessRisk(stocks, s.config)
+}
 
-	// Simple estimation based on analyst sentiment
-	upgradeCount, downgradeCount := s.countUpgradesDowngrades(stocks)
-	if upgradeCount > downgradeCount {
-		return float64(upgradeCount-downgradeCount) * 2.5 // 2.5% per net upgrade
-	} else if downgradeCount > upgradeCount {
-		return float64(upgradeCount-downgradeCount) * 2.5 // Negative return
-	}
-	return 5.0 // Default 5% expected return
+// calculateExpectedReturn estimates expected return from analysts' numeric price targets
+func (s *stockService) calculateExpectedReturn(stocks []models.Stock, referencePrice float64) targetEstimate {
+	return calculateTargetEstimate(stocks, referencePrice)
 }
 
 // generateReason creates a human-readable reason for the recommendation
-func (s *stockService) generateReason(stocks []models.Stock, score float64) string {
+func (s *stockService) generateReason(stocks []models.Stock, score float64, estimate targetEstimate) string {
 	upgradeCount, downgradeCount := s.countUpgradesDowngrades(stocks)
 	buyCount, sellCount, _ := s.countRatings(stocks)
 
@@ -366,6 +282,14 @@ func (s *stockService) generateReason(stocks []models.Stock, score float64) stri
 		reasons = append(reasons, fmt.Sprintf("%d sell ratings vs %d buy ratings", sellCount, buyCount))
 	}
 
+	if estimate.Count > 0 {
+		if estimate.Upside != nil {
+			reasons = append(reasons, fmt.Sprintf("Consensus target $%.2f implies %+.1f%% upside", estimate.Mean, *estimate.Upside))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("Consensus target $%.2f (%+.1f%% vs prior targets)", estimate.Mean, estimate.TargetChange))
+		}
+	}
+
 	if score >= 70 {
 		reasons = append(reasons, "Strong analyst consensus")
 	} else if score <= 30 {
@@ -398,10 +322,10 @@ func (s *stockService) countUpgradesDowngrades(stocks []models.Stock) (int, int)
 	downgradeCount := 0
 
 	for _, stock := range stocks {
-		action := strings.ToLower(stock.Action)
-		if strings.Contains(action, "upgrade") {
+		switch actionDirection(stock.Action) {
+		case 1:
 			upgradeCount++
-		} else if strings.Contains(action, "downgrade") {
+		case -1:
 			downgradeCount++
 		}
 	}
@@ -416,12 +340,12 @@ func (s *stockService) countRatings(stocks []models.Stock) (int, int, int) {
 	holdCount := 0
 
 	for _, stock := range stocks {
-		ratingTo := strings.ToLower(stock.RatingTo)
-		if strings.Contains(ratingTo, "buy") || strings.Contains(ratingTo, "outperform") || strings.Contains(ratingTo, "strong buy") {
+		switch ratingClass(stock.RatingTo) {
+		case ratingClassBuy:
 			buyCount++
-		} else if strings.Contains(ratingTo, "sell") || strings.Contains(ratingTo, "underperform") || strings.Contains(ratingTo, "strong sell") {
+		case ratingClassSell:
 			sellCount++
-		} else if strings.Contains(ratingTo, "hold") || strings.Contains(ratingTo, "neutral") {
+		case ratingClassHold:
 			holdCount++
 		}
 	}
@@ -429,7 +353,56 @@ func (s *stockService) countRatings(stocks []models.Stock) (int, int, int) {
 	return buyCount, sellCount, holdCount
 }
 
-// GetTopRecommendations retrieves top stock recommendations
-func (s *stockService) GetTopRecommendations(limit int) ([]models.StockRecommendation, error) {
-	return s.repo.GetTopRecommendations(limit)
+// actionDirection classifies an action as an upgrade (1), a downgrade (-1) or neither (0)
+func actionDirection(action string) int {
+	action = strings.ToLower(action)
+	if strings.Contains(action, "upgrade") {
+		return 1
+	} else if strings.Contains(action, "downgrade") {
+		return -1
+	}
+	return 0
+}
+
+// GetTopRecommendations retrieves a page of top stock recommendations and the cursor of the next
+// page, which is nil on the last page
+func (s *stockService) GetTopRecommendations(filter models.RecommendationFilter, withTotal bool) (*models.RecommendationPage, error) {
+	// One extra row tells whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	recommendations, err := s.repo.GetTopRecommendations(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.RecommendationPage{Recommendations: recommendations}
+	if withTotal {
+		total, err := s.repo.CountRecommendations(filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+	if len(recommendations) <= limit {
+		return page, nil
+	}
+	page.Recommendations = recommendations[:limit]
+
+	sort := filter.Sort
+	if len(sort) == 0 {
+		sort = models.RecommendationScoreSort
+	}
+	last := page.Recommendations[limit-1]
+	page.Next = &models.Cursor{Sort: sort, ID: last.ID}
+	for _, key := range sort {
+		page.Next.Values = append(page.Next.Values, last.SortValue(key.Field))
+	}
+	return page, nil
+}
+
+// StreamRecommendations calls fn with every recommendation matching a filter in the filter's sort
+// order, reading them from the database one at a time
+func (s *stockService) StreamRecommendations(ctx context.Context, filter models.RecommendationFilter,
+	fn func(recommendation models.StockRecommendation) error) error {
+	return s.repo.StreamRecommendations(ctx, filter, fn)
 }
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"truora-backend/internal/pkg/models"
+)
+
+// targetEstimate holds price-target statistics and the return derived from them
+type targetEstimate struct {
+	Count          int
+	Mean           float64
+	Median         float64
+	High           float64
+	Low            float64
+	TargetChange   float64  // average % change of target_to versus target_from
+	ExpectedReturn float64  // % return implied by the consensus target
+	ReturnLow      float64  // lowest % return across analysts
+	ReturnHigh     float64  // highest % return across analysts
+	Upside         *float64 // % upside of the mean target over the reference price
+}
+
+// latestByBrokerage returns the most recent event of each brokerage covering the ticker
+func latestByBrokerage(stocks []models.Stock) []models.Stock {
+	latest := make(map[string]models.Stock)
+	for _, stock := range stocks {
+		key := strings.ToLower(strings.TrimSpace(stock.Brokerage))
+		if current, ok := latest[key]; !ok || stock.Time.After(current.Time) {
+			latest[key] = stock
+		}
+	}
+
+	result := make([]models.Stock, 0, len(latest))
+	for _, stock := range latest {
+		result = append(result, stock)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.After(result[j].Time)
+	})
+	return result
+}
+
+// calculateTargetEstimate derives expected return and upside from analysts' price targets.
+// Consensus figures use each brokerage's latest target. When referencePrice is positive the
+// return is measured against it; otherwise it is the change versus prior targets.
+func calculateTargetEstimate(stocks []models.Stock, referencePrice float64) targetEstimate {
+	var estimate targetEstimate
+
+	var targets []float64
+	for _, stock := range latestByBrokerage(stocks) {
+		if target, ok := models.ParseTargetPrice(stock.TargetTo); ok {
+			targets = append(targets, target)
+		}
+	}
+
+	var changes []float64
+	for _, stock := range stocks {
+		from, okFrom := models.ParseTargetPrice(stock.TargetFrom)
+		to, okTo := models.ParseTargetPrice(stock.TargetTo)
+		if okFrom && okTo {
+			changes = append(changes, (to-from)/from*100)
+		}
+	}
+	if len(changes) > 0 {
+		estimate.TargetChange = clampPercent(mean(changes))
+	}
+
+	if len(targets) == 0 {
+		return estimate
+	}
+
+	sort.Float64s(targets)
+	estimate.Count = len(targets)
+	estimate.Mean = mean(targets)
+	estimate.Median = median(targets)
+	estimate.Low = targets[0]
+	estimate.High = targets[len(targets)-1]
+
+	if referencePrice > 0 {
+		upside := clampPercent((estimate.Mean - referencePrice) / referencePrice * 100)
+		estimate.Upside = &upside
+		estimate.ExpectedReturn = upside
+		estimate.ReturnLow = clampPercent((estimate.Low - referencePrice) / referencePrice * 100)
+		estimate.ReturnHigh = clampPercent((estimate.High - referencePrice) / referencePrice * 100)
+		return estimate
+	}
+
+	if len(changes) > 0 {
+		sort.Float64s(changes)
+		estimate.ExpectedReturn = estimate.TargetChange
+		estimate.ReturnLow = clampPercent(changes[0])
+		estimate.ReturnHigh = clampPercent(changes[len(changes)-1])
+	}
+	return estimate
+}
+
+// clampPercent keeps a percentage within the range storable as decimal(5,2)
+func clampPercent(value float64) float64 {
+	if value > 999.99 {
+		return 999.99
+	} else if value < -999.99 {
+		return -999.99
+	}
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"truora-backend/internal/pkg/models"
+)
+
+func TestCalculateTargetEstimate(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := []models.Stock{
+		{Brokerage: "Goldman Sachs", TargetFrom: "$90.00", TargetTo: "$100.00", Time: start},
+		{Brokerage: "goldman sachs ", TargetFrom: "$100.00", TargetTo: "$120.00", Time: start.AddDate(0, 0, 5)},
+		{Brokerage: "Morgan Stanley", TargetTo: "$1,150.00", Time: start.AddDate(0, 0, 1)},
+		{Brokerage: "Barclays", TargetFrom: "$0.00", TargetTo: "N/A", Time: start.AddDate(0, 0, 2)},
+	}
+	upside := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name           string
+		events         []models.Stock
+		referencePrice float64
+		want           targetEstimate
+	}{
+		{
+			name: "no events",
+		},
+		{
+			name:           "reference price measures return from each brokerage's latest target",
+			events:         events,
+			referencePrice: 500,
+			want: targetEstimate{
+				Count: 2, Mean: 635, Median: 635, High: 1150, Low: 120,
+				TargetChange: 15.56, ExpectedReturn: 27, ReturnLow: -76, ReturnHigh: 130,
+				Upside: upside(27),
+			},
+		},
+		{
+			name:   "without a reference price return is the change versus prior targets",
+			events: events,
+			want: targetEstimate{
+				Count: 2, Mean: 635, Median: 635, High: 1150, Low: 120,
+				TargetChange: 15.56, ExpectedReturn: 15.56, ReturnLow: 11.11, ReturnHigh: 20,
+			},
+		},
+		{
+			name:           "returns are clamped to the storable range",
+			events:         []models.Stock{{Brokerage: "Goldman Sachs", TargetTo: "$5,000.00", Time: start}},
+			referencePrice: 1,
+			want: targetEstimate{
+				Count: 1, Mean: 5000, Median: 5000, High: 5000, Low: 5000,
+				ExpectedReturn: 999.99, ReturnLow: 999.99, ReturnHigh: 999.99,
+				Upside: upside(999.99),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateTargetEstimate(tt.events, tt.referencePrice)
+			if (got.Upside == nil) != (tt.want.Upside == nil) || (got.Upside != nil && *got.Upside != *tt.want.Upside) {
+				t.Errorf("upside = %v, want %v", got.Upside, tt.want.Upside)
+			}
+			got.Upside, tt.want.Upside = nil, nil
+			if got != tt.want {
+				t.Errorf("estimate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -64,4 +64,4 @@ func getEnv(key, fallback string) string {
 		return value
 	}
 	return fallback
-}
\ No newline at end of file
+}
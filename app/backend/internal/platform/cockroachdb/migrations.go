@@ -8,8 +8,24 @@ import (
 
 // RunMigrations runs database migrations
 func RunMigrations(db *Database) error {
+	// Drop indexes of earlier schemas that AutoMigrate would keep
+	if err := dropLegacyIndexes(db); err != nil {
+		return fmt.Errorf("failed to drop legacy indexes: %w", err)
+	}
+
 	// Auto-migrate models
-	if err := db.DB.AutoMigrate(&models.Stock{}, &models.StockRecommendation{}); err != nil {
+	if err := db.DB.AutoMigrate(
+		&models.Stock{},
+		&models.StockRecommendation{},
+		&models.RecommendationRun{},
+		&models.RecommendationSnapshot{},
+		&models.StockPrice{},
+		&models.BrokerageScore{},
+		&models.TickerSector{},
+		&models.Portfolio{},
+		&models.PortfolioPosition{},
+		&models.Anomaly{},
+	); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
@@ -18,6 +34,35 @@ func RunMigrations(db *Database) error {
 		return fmt.Errorf("failed to create indexes: %w", err)
 	}
 
+	// Populate columns added after the initial schema
+	if err := backfillColumns(db); err != nil {
+		return fmt.Errorf("failed to backfill columns: %w", err)
+	}
+
+	// Unique keys that rows of earlier schemas may violate until deduplicated
+	if err := createUniqueKeys(db); err != nil {
+		return fmt.Errorf("failed to create unique keys: %w", err)
+	}
+
+	return nil
+}
+
+// dropLegacyIndexes drops indexes that earlier schemas created under names still in use, so
+// AutoMigrate and createIndexes recreate them with their current definition
+func dropLegacyIndexes(db *Database) error {
+	// stocks.ticker was unique, keeping a single analyst event per ticker; events are now unique by
+	// ticker, brokerage and time and the ticker index is a plain one
+	var unique int64
+	if err := db.DB.Raw(`SELECT COUNT(*) FROM pg_indexes
+		WHERE tablename = 'stocks' AND indexname = 'idx_stocks_ticker' AND indexdef LIKE 'CREATE UNIQUE INDEX%'`).
+		Scan(&unique).Error; err != nil {
+		return fmt.Errorf("failed to inspect ticker index: %w", err)
+	}
+	if unique > 0 {
+		if err := db.DB.Exec("DROP INDEX IF EXISTS idx_stocks_ticker CASCADE").Error; err != nil {
+			return fmt.Errorf("failed to drop unique ticker index: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -48,6 +93,19 @@ func createIndexes(db *Database) error {
 		return fmt.Errorf("failed to create rating_to index: %w", err)
 	}
 
+	// Case-insensitive indexes for the stocks listing's brokerage, action and rating filters
+	for _, column := range []string{"brokerage", "action", "rating_from", "rating_to"} {
+		sql := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_stocks_%s_lower ON stocks(LOWER(%s))", column, column)
+		if err := db.DB.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to create %s lower index: %w", column, err)
+		}
+	}
+
+	// Index on the numeric target for target range filters
+	if err := db.DB.Exec("CREATE INDEX IF NOT EXISTS idx_stocks_target_to_value ON stocks(target_to_value)").Error; err != nil {
+		return fmt.Errorf("failed to create target_to_value index: %w", err)
+	}
+
 	// Index on time for chronological queries
 	if err := db.DB.Exec("CREATE INDEX IF NOT EXISTS idx_stocks_time ON stocks(time DESC)").Error; err != nil {
 		return fmt.Errorf("failed to create time index: %w", err)
@@ -73,6 +131,54 @@ func createIndexes(db *Database) error {
 		return fmt.Errorf("failed to create ticker_time index: %w", err)
 	}
 
+	// Index on updated_at for finding tickers with new events since the last recommendation run
+	if err := db.DB.Exec("CREATE INDEX IF NOT EXISTS idx_stocks_updated_at ON stocks(updated_at)").Error; err != nil {
+		return fmt.Errorf("failed to create updated_at index: %w", err)
+	}
+
+	// Composite index for a ticker's rank history
+	if err := db.DB.Exec("CREATE INDEX IF NOT EXISTS idx_snapshots_ticker_created ON recommendation_snapshots(ticker, time_horizon, created_at DESC)").Error; err != nil {
+		return fmt.Errorf("failed to create snapshot ticker index: %w", err)
+	}
+
+	return nil
+}
+
+// backfillColumns fills columns that older rows were created without
+func backfillColumns(db *Database) error {
+	// Recommendations created before they carried their ticker
+	if err := db.DB.Exec(`UPDATE stock_recommendations SET ticker = stocks.ticker
+		FROM stocks
+		WHERE stock_recommendations.stock_id = stocks.id
+		AND (stock_recommendations.ticker IS NULL OR stock_recommendations.ticker = '')`).Error; err != nil {
+		return fmt.Errorf("failed to backfill recommendation tickers: %w", err)
+	}
+
+	// Events stored before targets had numeric copies; the pattern matches what fits decimal(12,2)
+	for _, column := range []string{"target_from", "target_to"} {
+		if err := db.DB.Exec(fmt.Sprintf(`UPDATE stocks
+			SET %[1]s_value = NULLIF(CAST(REPLACE(REPLACE(TRIM(%[1]s), '$', ''), ',', '') AS DECIMAL(12,2)), 0)
+			WHERE %[1]s_value IS NULL
+			AND TRIM(%[1]s) ~ '^\$?[0-9]{1,3}(,?[0-9]{3}){0,2}(\.[0-9]+)?$'`, column)).Error; err != nil {
+			return fmt.Errorf("failed to backfill %s values: %w", column, err)
+		}
+	}
+
+	return nil
+}
+
+// createUniqueKeys removes duplicate rows left by earlier schemas and creates the unique indexes
+// upserts rely on
+func createUniqueKeys(db *Database) error {
+	// Recommendations were replaced by delete and insert; keep the live, latest one per ticker and horizon
+	if err := db.DB.Exec(`DELETE FROM stock_recommendations WHERE id NOT IN (
+		SELECT DISTINCT ON (ticker, time_horizon) id FROM stock_recommendations
+		ORDER BY ticker, time_horizon, deleted_at IS NULL DESC, updated_at DESC, id DESC)`).Error; err != nil {
+		return fmt.Errorf("failed to remove duplicate recommendations: %w", err)
+	}
+	if err := db.DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_recommendations_ticker_horizon ON stock_recommendations(ticker, time_horizon)").Error; err != nil {
+		return fmt.Errorf("failed to create recommendation ticker and horizon index: %w", err)
+	}
 	return nil
 }
 
@@ -81,6 +187,14 @@ func (d *Database) DropTables() error {
 	log.Println("Dropping all tables...")
 
 	err := d.DB.Migrator().DropTable(
+		&models.Anomaly{},
+		&models.PortfolioPosition{},
+		&models.Portfolio{},
+		&models.TickerSector{},
+		&models.BrokerageScore{},
+		&models.StockPrice{},
+		&models.RecommendationSnapshot{},
+		&models.RecommendationRun{},
 		&models.StockRecommendation{},
 		&models.Stock{},
 	)
@@ -1,4 +1,4 @@
-import type { Stock, StockRecommendation, ApiResponse } from '@/types'
+import type { Stock, StockDetail, StockRecommendation, ApiResponse } from '@/types'
 
 const API_BASE_URL = import.meta.env.VITE_API_URL || 'http://localhost:8000/api/v1'
 
@@ -36,7 +36,7 @@ class ApiService {
     return this.request(`/stocks${query ? `?${query}` : ''}`)
   }
 
-  async getStockByTicker(ticker: string): Promise<ApiResponse<Stock>> {
+  async getStockByTicker(ticker: string): Promise<ApiResponse<StockDetail>> {
     return this.request(`/stocks/${ticker}`)
   }
 
@@ -1,13 +1,13 @@
 import { defineStore } from 'pinia'
 import { ref, computed } from 'vue'
-import type { Stock, StockRecommendation, SearchFilters } from '@/types'
+import type { Stock, StockDetail, StockRecommendation, SearchFilters } from '@/types'
 import { apiService } from '@/services/api'
 
 export const useStocksStore = defineStore('stocks', () => {
   // State
   const stocks = ref<Stock[]>([])
   const recommendations = ref<StockRecommendation[]>([])
-  const selectedStock = ref<Stock | null>(null)
+  const selectedStock = ref<StockDetail | null>(null)
   const loading = ref(false)
   const error = ref<string | null>(null)
   const searchQuery = ref('')
@@ -30,6 +30,70 @@ export interface StockRecommendation {
   target_from?: string
 }
 
+export interface RatingDistribution {
+  strong_buy: number
+  buy: number
+  hold: number
+  sell: number
+  strong_sell: number
+  unrated: number
+}
+
+export interface Consensus {
+  ticker: string
+  company: string
+  consensus_rating: string
+  consensus_score: number
+  rating_distribution: RatingDistribution
+  target_count: number
+  target_mean: number
+  target_median: number
+  target_high: number
+  target_low: number
+  reference_price: number | null
+  price_date: string | null
+  upside: number | null
+  brokerage_count: number
+  last_change: string
+}
+
+export interface RecommendationSnapshot {
+  id: number
+  run_id: number
+  ticker: string
+  time_horizon: string
+  rank: number
+  score: number
+  percentile: number | null
+  z_score: number | null
+  grade: string | null
+  created_at: string
+}
+
+export interface TimelineEvent {
+  id: number
+  time: string
+  brokerage: string
+  action: string
+  rating_from: string
+  rating_to: string
+  rating_change: number | null
+  target_from: number | null
+  target_to: number | null
+  target_change: number | null
+}
+
+export interface StockDetail {
+  ticker: string
+  company: string
+  sector?: string
+  industry?: string
+  consensus: Consensus
+  recommendation: StockRecommendation | null
+  recommendation_history: RecommendationSnapshot[]
+  timeline: TimelineEvent[]
+}
+
 export interface PaginationInfo {
   limit: number
   offset: number